@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type accessListResult struct {
+	AccessList *types.AccessList `json:"accessList"`
+	Error      string            `json:"error,omitempty"`
+	GasUsed    hexutil.Uint64    `json:"gasUsed"`
+}
+
+// createAccessList returns the access list generated for the call, the gas used by the
+// call with the access list applied and the execution error of the call, if any.
+func createAccessList(ctx context.Context, client rpcCaller, msg ethereum.CallMsg, number *big.Int) (*types.AccessList, uint64, string, error) {
+	var result accessListResult
+	if err := client.Call(ctx, &result, "eth_createAccessList", toCallArg(msg), toBlockNumArg(number)); err != nil {
+		return nil, 0, "", err
+	}
+	if result.AccessList == nil {
+		result.AccessList = &types.AccessList{}
+	}
+	return result.AccessList, uint64(result.GasUsed), result.Error, nil
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// accessListService reports an execution error for calls with a value, and a null
+// access list for calls without data.
+type accessListService struct {
+	number string
+}
+
+func (s *accessListService) CreateAccessList(args map[string]interface{}, number string) map[string]interface{} {
+	s.number = number
+	result := map[string]interface{}{"gasUsed": "0x6d60"}
+	if _, ok := args["value"]; ok {
+		result["error"] = "execution reverted"
+	}
+	if _, ok := args["data"]; ok {
+		result["accessList"] = []map[string]interface{}{{
+			"address":     args["to"],
+			"storageKeys": []common.Hash{common.HexToHash("0x01")},
+		}}
+	}
+	return result
+}
+
+func TestCreateAccessList(t *testing.T) {
+	service := &accessListService{}
+	ec := newTestClient(t, service, Capabilities{})
+	defer ec.Close()
+
+	to := common.HexToAddress("0x01")
+	accessList, gasUsed, vmErr, err := ec.CreateAccessList(context.Background(), ethereum.CallMsg{To: &to, Data: []byte{0x01}}, big.NewInt(16))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x10", service.number)
+	assert.Equal(t, &types.AccessList{{Address: to, StorageKeys: []common.Hash{common.HexToHash("0x01")}}}, accessList)
+	assert.Equal(t, uint64(28000), gasUsed)
+	assert.Empty(t, vmErr)
+
+	accessList, _, vmErr, err = ec.CreateAccessList(context.Background(), ethereum.CallMsg{To: &to, Value: big.NewInt(1)}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "latest", service.number)
+	assert.Equal(t, &types.AccessList{}, accessList)
+	assert.Equal(t, "execution reverted", vmErr)
+}
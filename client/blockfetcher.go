@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	defaultFetcherWindow    = 256 // maximum number of blocks fetched ahead of the next delivered block
+	blockFetchRetries       = 3
+	blockFetchRetryInterval = 1 * time.Second
+	minFetcherWorkers       = 2
+)
+
+// FetchedBlock is a block delivered by BlockRangeFetcher, Receipts is only set when the
+// fetcher is configured to fetch receipts.
+type FetchedBlock struct {
+	Block    *types.Block
+	Receipts types.Receipts
+}
+
+// BlockRangeFetcherConfig holds the options of BlockRangeFetcher, zero values are replaced
+// by defaults.
+type BlockRangeFetcherConfig struct {
+	FullBlock bool // fetch full transactions instead of transaction hashes
+	Receipts  bool // fetch the receipts of every block
+	Workers   int  // number of concurrent requests, defaults to twice the pool size
+	Window    int  // maximum number of fetched blocks waiting to be delivered
+}
+
+// blockFetcherBackend is the subset of RemoteChainReader needed to fetch blocks.
+type blockFetcherBackend interface {
+	BlockByNumber(ctx context.Context, number *big.Int, fullBlock bool) (*types.Block, error)
+	BlockReceipts(ctx context.Context, numberOrHash interface{}) (types.Receipts, error)
+}
+
+// BlockRangeFetcher fetches the blocks [from, to] concurrently across the clients of a
+// connection pool and delivers them in strict number order. At most Window blocks are
+// held in memory at any time.
+type BlockRangeFetcher struct {
+	backend   blockFetcherBackend
+	from, to  uint64
+	fullBlock bool
+	receipts  bool
+	workers   int
+	window    int
+}
+
+func NewBlockRangeFetcher(pool *RpcConnectionPool, from, to uint64, config *BlockRangeFetcherConfig) *BlockRangeFetcher {
+	f := &BlockRangeFetcher{
+		backend: pool,
+		from:    from,
+		to:      to,
+		workers: 2 * pool.Size(),
+		window:  defaultFetcherWindow,
+	}
+	if f.workers < minFetcherWorkers {
+		f.workers = minFetcherWorkers
+	}
+	if config != nil {
+		f.fullBlock = config.FullBlock
+		f.receipts = config.Receipts
+		if config.Workers > 0 {
+			f.workers = config.Workers
+		}
+		if config.Window > 0 {
+			f.window = config.Window
+		}
+	}
+	return f
+}
+
+type fetchResult struct {
+	number uint64
+	block  *FetchedBlock
+	err    error
+}
+
+func (f *BlockRangeFetcher) fetchBlock(ctx context.Context, number uint64) (*FetchedBlock, error) {
+	block, err := f.backend.BlockByNumber(ctx, new(big.Int).SetUint64(number), f.fullBlock)
+	if err != nil {
+		return nil, err
+	}
+	if block.NumberU64() != number {
+		return nil, fmt.Errorf("got wrong block number %d, expected %d", block.NumberU64(), number)
+	}
+	fetched := &FetchedBlock{Block: block}
+	if f.receipts {
+		if fetched.Receipts, err = f.backend.BlockReceipts(ctx, block.Hash()); err != nil {
+			return nil, err
+		}
+	}
+	return fetched, nil
+}
+
+func (f *BlockRangeFetcher) fetchWithRetry(ctx context.Context, number uint64) (*FetchedBlock, error) {
+	var err error
+	for attempt := 0; attempt < blockFetchRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt) * blockFetchRetryInterval):
+			}
+		}
+		var block *FetchedBlock
+		if block, err = f.fetchBlock(ctx, number); err == nil {
+			return block, nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		log.Debug("Failed to fetch block", "number", number, "attempt", attempt+1, "error", err)
+	}
+	return nil, fmt.Errorf("failed to fetch block %d: %w", number, err)
+}
+
+// Fetch fetches the blocks and calls fn with each of them in number order. It stops at
+// the first error returned by fn or by a request that failed after retrying, or when the
+// context is cancelled.
+func (f *BlockRangeFetcher) Fetch(ctx context.Context, fn func(*FetchedBlock) error) error {
+	if f.from > f.to {
+		return fmt.Errorf("invalid block range [%d, %d]", f.from, f.to)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// the requests of the workers start on the clients in turn, so that the work is spread
+	// across the pool regardless of its strategy
+	ctx = withStrategy(ctx, NewRoundRobinStrategy())
+
+	var (
+		numbers = make(chan uint64)
+		results = make(chan fetchResult, f.workers)
+		slots   = make(chan struct{}, f.window) // one slot per block not delivered yet
+		wg      sync.WaitGroup
+	)
+	go func() {
+		defer close(numbers)
+		for number := f.from; ; number++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case numbers <- number:
+			case <-ctx.Done():
+				return
+			}
+			if number == f.to {
+				return
+			}
+		}
+	}()
+	for idx := 0; idx < f.workers; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for number := range numbers {
+				block, err := f.fetchWithRetry(ctx, number)
+				select {
+				case results <- fetchResult{number, block, err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[uint64]*FetchedBlock)
+	next := f.from
+	for res := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if res.err != nil {
+			return res.err
+		}
+		pending[res.number] = res.block
+		for block, ok := pending[next]; ok; block, ok = pending[next] {
+			delete(pending, next)
+			if err := fn(block); err != nil {
+				return err
+			}
+			if next == f.to {
+				return nil
+			}
+			next++
+			<-slots
+		}
+	}
+	return ctx.Err()
+}
+
+// FetchChan is like Fetch but sends the blocks to ch, ch is closed when FetchChan returns.
+func (f *BlockRangeFetcher) FetchChan(ctx context.Context, ch chan<- *FetchedBlock) error {
+	defer close(ch)
+	return f.Fetch(ctx, func(block *FetchedBlock) error {
+		select {
+		case ch <- block:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowChain serves blocks after a random delay and records the number of blocks fetched
+// but not yet delivered.
+type slowChain struct {
+	outstanding    int64
+	maxOutstanding int64
+}
+
+func (c *slowChain) BlockByNumber(ctx context.Context, number *big.Int, fullBlock bool) (*types.Block, error) {
+	if n := atomic.AddInt64(&c.outstanding, 1); n > atomic.LoadInt64(&c.maxOutstanding) {
+		atomic.StoreInt64(&c.maxOutstanding, n)
+	}
+	time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+	return types.NewBlockWithHeader(&types.Header{Number: number}), nil
+}
+
+func (c *slowChain) BlockReceipts(ctx context.Context, numberOrHash interface{}) (types.Receipts, error) {
+	return types.Receipts{}, nil
+}
+
+func TestBlockRangeFetcherOrder(t *testing.T) {
+	chain := &slowChain{}
+	fetcher := &BlockRangeFetcher{backend: chain, from: 100, to: 299, receipts: true, workers: 8, window: 16}
+	next := uint64(100)
+	err := fetcher.Fetch(context.Background(), func(block *FetchedBlock) error {
+		assert.Equal(t, next, block.Block.NumberU64())
+		assert.NotNil(t, block.Receipts)
+		atomic.AddInt64(&chain.outstanding, -1)
+		next++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(300), next)
+	assert.LessOrEqual(t, chain.maxOutstanding, int64(16))
+}
+
+func TestBlockRangeFetcherCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fetcher := &BlockRangeFetcher{backend: &slowChain{}, from: 0, to: 1000, workers: 4, window: 8}
+	delivered := 0
+	err := fetcher.Fetch(ctx, func(block *FetchedBlock) error {
+		if delivered++; delivered == 10 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, delivered, 20)
+}
+
+// countingBlockService serves headers after a short delay and counts the blocks it served.
+type countingBlockService struct {
+	served atomic.Int32
+}
+
+func (s *countingBlockService) GetBlockByNumber(number hexutil.Uint64, fullBlock bool) *types.Header {
+	s.served.Add(1)
+	time.Sleep(time.Millisecond)
+	return &types.Header{Number: new(big.Int).SetUint64(uint64(number)), Difficulty: big.NewInt(0)}
+}
+
+func TestBlockRangeFetcherSpreadsRequests(t *testing.T) {
+	services := make([]*countingBlockService, 3)
+	clients := make([]*ETHClient, len(services))
+	for idx := range services {
+		services[idx] = &countingBlockService{}
+		clients[idx] = newTestClient(t, services[idx], Capabilities{})
+	}
+	// the pool alone would send every request to the first client
+	pool := NewRpcConnectionPool(clients)
+	defer pool.Close()
+
+	fetcher := NewBlockRangeFetcher(pool, 1, 300, nil)
+	assert.NoError(t, fetcher.Fetch(context.Background(), func(block *FetchedBlock) error { return nil }))
+	for _, service := range services {
+		assert.InDelta(t, 100, service.served.Load(), 5)
+	}
+}
@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const rpcProbeTimeout = 10 * time.Second
+
+var (
+	// probeBatchSizes are the batch sizes tried when probing, smallest first so that
+	// endpoints with low limits only see small requests.
+	probeBatchSizes = []int{10, 50, 100, 500, 1000}
+	// probeLogsRanges are the eth_getLogs block ranges tried when probing, smallest first.
+	probeLogsRanges = []uint64{10, 100, 500, 1_000, 2_000, 5_000, 10_000, 100_000}
+)
+
+// Capabilities is the set of features an endpoint was found to support when connecting.
+// Features whose probe was inconclusive, like after a timeout, are reported as missing
+// and probed again on the next connect.
+type Capabilities struct {
+	Archive       bool   // serves state of old blocks
+	Debug         bool   // debug namespace
+	Trace         bool   // trace namespace
+	TxPool        bool   // txpool namespace
+	BlockReceipts bool   // eth_getBlockReceipts
+	SimulateV1    bool   // eth_simulateV1
+	MaxBatchSize  int    // largest accepted batch request, zero if batching is not supported
+	MaxLogsRange  uint64 // largest accepted eth_getLogs block range, zero if unknown
+	WebSocket     bool   // supports subscriptions
+}
+
+// Capabilities returns the capabilities of the endpoint probed on the last connect.
+func (ec *ETHClient) Capabilities() Capabilities {
+	if caps := ec.caps.Load(); caps != nil {
+		return *caps
+	}
+	return Capabilities{}
+}
+
+// probeMethod reports whether the endpoint implements the method, the arguments are only
+// meant to be cheap. Only a method not found error means the method is missing, other
+// errors like rate limits and timeouts do not tell and the method is assumed to exist.
+func (ec *ETHClient) probeMethod(ctx context.Context, method string, args ...interface{}) bool {
+	ctx, cancel := context.WithTimeout(ctx, rpcProbeTimeout)
+	defer cancel()
+	var result interface{}
+	err := ec.Call(ctx, &result, method, args...)
+	if isMethodNotFoundError(err) {
+		ec.unsupportedMethods.Store(method, true)
+		return false
+	}
+	ec.unsupportedMethods.Delete(method)
+	return true
+}
+
+// isRPCError reports whether err is an error response of the server, as opposed to a
+// transport failure.
+func isRPCError(err error) bool {
+	// a classified error forwards the code of any error it wraps
+	var classified *ClassifiedError
+	if errors.As(err, &classified) {
+		err = classified.Err
+	}
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr)
+}
+
+// isInconclusive reports whether the error of a probe tells nothing about the endpoint.
+func isInconclusive(err error) bool {
+	class := classifyError(err)
+	return class == ErrRateLimited || isOutage(class) || errors.Is(err, context.Canceled)
+}
+
+// probeArchive reports whether the endpoint serves the state of old blocks, and whether
+// the probe was conclusive. Only a missing state error means the node is pruned.
+func (ec *ETHClient) probeArchive(ctx context.Context, head uint64) (bool, bool) {
+	if head <= archiveStateDepth {
+		return false, head > 0
+	}
+	ctx, cancel := context.WithTimeout(ctx, rpcProbeTimeout)
+	defer cancel()
+	// pruned nodes only keep the state of the recent blocks
+	var result hexutil.Big
+	err := ec.Call(ctx, &result, "eth_getBalance", common.Address{}, hexutil.EncodeUint64(1))
+	if err == nil {
+		return true, true
+	}
+	return false, errors.Is(err, ErrHeaderNotFound)
+}
+
+// probeMaxBatchSize returns the largest accepted batch size, and whether the probe was
+// conclusive.
+func (ec *ETHClient) probeMaxBatchSize(ctx context.Context) (int, bool) {
+	maxSize := 0
+	for _, size := range probeBatchSizes {
+		ctx, cancel := context.WithTimeout(ctx, rpcProbeTimeout)
+		results := make([]hexutil.Uint64, size)
+		batch := make([]rpc.BatchElem, size)
+		for idx := range batch {
+			batch[idx] = rpc.BatchElem{Method: "eth_blockNumber", Result: &results[idx]}
+		}
+		err := ec.client.BatchCallContext(ctx, batch)
+		if err == nil {
+			err = getBatchErr(batch)
+		}
+		cancel()
+		if err != nil {
+			return maxSize, !isInconclusive(err)
+		}
+		maxSize = size
+	}
+	return maxSize, true
+}
+
+// probeMaxLogsRange returns the largest accepted eth_getLogs block range, and whether the
+// probe was conclusive.
+func (ec *ETHClient) probeMaxLogsRange(ctx context.Context, head uint64) (uint64, bool) {
+	if head == 0 {
+		return 0, false
+	}
+	var maxRange uint64
+	for _, size := range probeLogsRanges {
+		if size > head {
+			break
+		}
+		// the zero address emits no logs, the query is cheap on nodes with log indexes
+		arg, err := toFilterArg(ethereum.FilterQuery{
+			Addresses: []common.Address{{}},
+			FromBlock: new(big.Int).SetUint64(head - size + 1),
+			ToBlock:   new(big.Int).SetUint64(head),
+		})
+		if err != nil {
+			return maxRange, true
+		}
+		ctx, cancel := context.WithTimeout(ctx, rpcProbeTimeout)
+		var logs []json.RawMessage
+		err = ec.Call(ctx, &logs, "eth_getLogs", arg)
+		cancel()
+		if err != nil {
+			// larger ranges are unknown unless the endpoint rejected the range
+			return maxRange, isLogRangeError(err)
+		}
+		maxRange = size
+	}
+	return maxRange, true
+}
+
+func (ec *ETHClient) probeSubscriptions(ctx context.Context) bool {
+	if !supportsSubscriptions(ec.url) {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, rpcProbeTimeout)
+	defer cancel()
+	sub, err := ec.client.EthSubscribe(ctx, make(chan interface{}), "newHeads")
+	if err != nil {
+		return false
+	}
+	sub.Unsubscribe()
+	return true
+}
+
+// probeCapabilities detects the capabilities of the endpoint, the probes are run
+// concurrently and bounded by ctx. It also reports whether all probes were conclusive.
+func (ec *ETHClient) probeCapabilities(ctx context.Context) (*Capabilities, bool) {
+	var (
+		caps                                  Capabilities
+		head                                  uint64
+		wg                                    sync.WaitGroup
+		archiveDone, batchDone, logsRangeDone bool
+	)
+	headCtx, cancel := context.WithTimeout(ctx, rpcProbeTimeout)
+	if number, err := ec.BlockNumber(headCtx); err == nil {
+		head = number.Uint64()
+	}
+	cancel()
+	probes := []func(){
+		func() { caps.Archive, archiveDone = ec.probeArchive(ctx, head) },
+		func() { caps.Debug = ec.probeMethod(ctx, "debug_traceTransaction", common.Hash{}) },
+		func() { caps.Trace = ec.probeMethod(ctx, "trace_transaction", common.Hash{}) },
+		func() { caps.TxPool = ec.probeMethod(ctx, "txpool_status") },
+		func() { caps.BlockReceipts = ec.probeMethod(ctx, "eth_getBlockReceipts", toBlockNumArg(big.NewInt(0))) },
+		func() {
+			caps.SimulateV1 = ec.probeMethod(ctx, "eth_simulateV1", map[string]interface{}{"blockStateCalls": []interface{}{}}, "latest")
+		},
+		func() { caps.MaxBatchSize, batchDone = ec.probeMaxBatchSize(ctx) },
+		func() { caps.MaxLogsRange, logsRangeDone = ec.probeMaxLogsRange(ctx, head) },
+		func() { caps.WebSocket = ec.probeSubscriptions(ctx) },
+	}
+	for _, probe := range probes {
+		wg.Add(1)
+		go func(probe func()) {
+			defer wg.Done()
+			probe()
+		}(probe)
+	}
+	wg.Wait()
+	log.Debug("Probed RPC endpoint capabilities", "url", ec.url, "archive", caps.Archive, "debug", caps.Debug,
+		"trace", caps.Trace, "txpool", caps.TxPool, "blockReceipts", caps.BlockReceipts, "simulate", caps.SimulateV1,
+		"batch", caps.MaxBatchSize, "logsRange", caps.MaxLogsRange, "ws", caps.WebSocket)
+	return &caps, archiveDone && batchDone && logsRangeDone
+}
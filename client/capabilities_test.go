@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// prunedEthService emulates a pruned node limiting eth_getLogs to 2000 blocks.
+type prunedEthService struct{}
+
+func (s *prunedEthService) BlockNumber() hexutil.Uint64 {
+	return 50_000
+}
+
+func (s *prunedEthService) GetBalance(account common.Address, number string) (*hexutil.Big, error) {
+	return nil, errors.New("missing trie node")
+}
+
+func (s *prunedEthService) GetLogs(crit map[string]interface{}) ([]interface{}, error) {
+	from, _ := hexutil.DecodeUint64(crit["fromBlock"].(string))
+	to, _ := hexutil.DecodeUint64(crit["toBlock"].(string))
+	if to-from+1 > 2000 {
+		return nil, errors.New("block range too large")
+	}
+	return []interface{}{}, nil
+}
+
+type txpoolService struct{}
+
+func (s *txpoolService) Status() map[string]hexutil.Uint {
+	return map[string]hexutil.Uint{"pending": 0, "queued": 0}
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	assert.NoError(t, server.RegisterName("eth", &prunedEthService{}))
+	assert.NoError(t, server.RegisterName("txpool", &txpoolService{}))
+	ec := &ETHClient{url: "http://inproc", client: rpc.DialInProc(server)}
+	defer ec.Close()
+
+	caps, conclusive := ec.probeCapabilities(context.Background())
+	assert.True(t, conclusive)
+	assert.Equal(t, Capabilities{TxPool: true, MaxBatchSize: 1000, MaxLogsRange: 2000}, *caps)
+	assert.False(t, ec.supportsMethod("eth_getBlockReceipts"))
+}
+
+// meteredEthService counts the eth_getLogs requests and answers every request slowly, the
+// first balance requests are rate limited.
+type meteredEthService struct {
+	delay       time.Duration
+	logs        atomic.Int32
+	rateLimited atomic.Int32
+}
+
+func (s *meteredEthService) GetBalance(account common.Address, number string) (*hexutil.Big, error) {
+	if s.rateLimited.Add(-1) >= 0 {
+		return nil, &rateLimitError{}
+	}
+	return (*hexutil.Big)(big.NewInt(0)), nil
+}
+
+func (s *meteredEthService) BlockNumber() hexutil.Uint64 {
+	time.Sleep(s.delay)
+	return 50_000
+}
+
+func (s *meteredEthService) GetLogs(crit map[string]interface{}) []interface{} {
+	s.logs.Add(1)
+	time.Sleep(s.delay)
+	return []interface{}{}
+}
+
+type netService struct{}
+
+func (s *netService) Version() string { return "1" }
+
+type web3Service struct{}
+
+func (s *web3Service) ClientVersion() string { return "test/v1.0.0" }
+
+func TestProbeCapabilitiesOnce(t *testing.T) {
+	service := &meteredEthService{}
+	server := rpc.NewServer()
+	defer server.Stop()
+	assert.NoError(t, server.RegisterName("eth", service))
+	assert.NoError(t, server.RegisterName("net", &netService{}))
+	assert.NoError(t, server.RegisterName("web3", &web3Service{}))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	ec, err := DialContext(context.Background(), httpServer.URL)
+	assert.NoError(t, err)
+	defer ec.Close()
+	assert.Equal(t, uint64(10_000), ec.Capabilities().MaxLogsRange)
+	probes := service.logs.Load()
+	assert.NotZero(t, probes)
+
+	// reconnecting after a cooldown does not probe again
+	assert.NoError(t, ec.connect(context.Background()))
+	assert.Equal(t, probes, service.logs.Load())
+	assert.Equal(t, uint64(10_000), ec.Capabilities().MaxLogsRange)
+}
+
+func TestProbeCapabilitiesRetriesInconclusive(t *testing.T) {
+	service := &meteredEthService{}
+	service.rateLimited.Store(1)
+	server := rpc.NewServer()
+	defer server.Stop()
+	assert.NoError(t, server.RegisterName("eth", service))
+	assert.NoError(t, server.RegisterName("net", &netService{}))
+	assert.NoError(t, server.RegisterName("web3", &web3Service{}))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	// a rate limited probe does not mark the endpoint as pruned for good
+	ec, err := DialContext(context.Background(), httpServer.URL)
+	assert.NoError(t, err)
+	defer ec.Close()
+	assert.False(t, ec.Capabilities().Archive)
+	assert.False(t, ec.capsProbed.Load())
+
+	assert.NoError(t, ec.connect(context.Background()))
+	assert.True(t, ec.Capabilities().Archive)
+	assert.True(t, ec.capsProbed.Load())
+}
+
+func TestProbeCapabilitiesHonorsContext(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	assert.NoError(t, server.RegisterName("eth", &meteredEthService{delay: 2 * time.Second}))
+	ec := &ETHClient{url: "http://inproc", client: rpc.DialInProc(server)}
+	defer ec.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	ec.probeCapabilities(ctx)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestProbeMethodInconclusive(t *testing.T) {
+	ec := newTestClient(t, &revertingService{}, Capabilities{})
+	defer ec.Close()
+
+	// errors other than method not found do not mark the method as missing
+	assert.True(t, ec.probeMethod(context.Background(), "eth_call", map[string]interface{}{}, "latest"))
+	assert.True(t, ec.supportsMethod("eth_call"))
+	assert.False(t, ec.probeMethod(context.Background(), "debug_traceTransaction", common.Hash{}))
+	assert.False(t, ec.supportsMethod("debug_traceTransaction"))
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const rpcHealthCheckInterval = 1 * time.Minute
+
+// ChainMismatchError is returned when an endpoint serves another chain than the expected one.
+type ChainMismatchError struct {
+	URL      string
+	Method   string   // method that reported the unexpected identifier
+	Expected *big.Int // expected chain or network identifier
+	Actual   *big.Int
+}
+
+func (e *ChainMismatchError) Error() string {
+	return fmt.Sprintf("endpoint %s reported %s %v, expected %v", e.URL, e.Method, e.Actual, e.Expected)
+}
+
+// verifyChain checks that the endpoint reports the expected chain id on eth_chainId and
+// the expected network id on net_version, a nil networkID is not checked.
+func (ec *ETHClient) verifyChain(ctx context.Context, chainID, networkID *big.Int) error {
+	actual, err := ec.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	if actual.Cmp(chainID) != 0 {
+		return &ChainMismatchError{URL: ec.url, Method: "eth_chainId", Expected: chainID, Actual: actual}
+	}
+	if networkID == nil {
+		return nil
+	}
+	if actual, err = ec.NetworkID(ctx); err != nil {
+		return err
+	}
+	if actual.Cmp(networkID) != 0 {
+		return &ChainMismatchError{URL: ec.url, Method: "net_version", Expected: networkID, Actual: actual}
+	}
+	return nil
+}
+
+// evict permanently removes the client from the pool.
+func (p *RpcConnectionPool) evict(idx int, client *ETHClient, reason error) {
+	log.Error("Evicted RPC endpoint from pool", "url", client.url, "reason", reason)
+	atomic.StoreInt64(&p.status[idx], clientStatusEvicted)
+	client.Close()
+}
+
+// verifyChain checks the chain of the client if the pool expects one, the client is
+// evicted on mismatch.
+func (p *RpcConnectionPool) verifyChain(ctx context.Context, idx int, client *ETHClient) error {
+	if p.chainID == nil {
+		return nil
+	}
+	err := client.verifyChain(ctx, p.chainID, p.networkID)
+	if _, mismatch := err.(*ChainMismatchError); mismatch {
+		p.evict(idx, client, err)
+	}
+	return err
+}
+
+func (p *RpcConnectionPool) checkChains() {
+	for idx, client := range p.clients {
+		if !p.isHealthy(idx) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), rpcRequestTimeout)
+		if err := p.verifyChain(ctx, idx, client); err != nil {
+			log.Debug("RPC endpoint health check failed", "url", client.url, "error", err)
+		}
+		cancel()
+	}
+}
+
+func (p *RpcConnectionPool) healthCheckLoop() {
+	ticker := time.NewTicker(rpcHealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.quitCh:
+			return
+		case <-ticker.C:
+			p.checkChains()
+		}
+	}
+}
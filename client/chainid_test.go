@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type chainService struct {
+	chainID int64
+}
+
+func (s *chainService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(s.chainID))
+}
+
+func TestPoolEvictsOtherChain(t *testing.T) {
+	mainnet := newTestClient(t, &chainService{chainID: 1}, Capabilities{})
+	sepolia := newTestClient(t, &chainService{chainID: 11155111}, Capabilities{})
+	pool := newRpcConnectionPool([]*ETHClient{sepolia, mainnet}, &PoolConfig{ChainID: big.NewInt(1)})
+	defer pool.Close()
+
+	var mismatch *ChainMismatchError
+	err := sepolia.verifyChain(context.Background(), big.NewInt(1), nil)
+	assert.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "eth_chainId", mismatch.Method)
+
+	pool.checkChains()
+	assert.Equal(t, clientStatusEvicted, pool.status[0])
+	assert.False(t, pool.setStatus(0, clientStatusActive))
+
+	chainID, err := pool.ChainID(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), chainID)
+}
+
+// unreliableChainService fails every eth_chainId request.
+type unreliableChainService struct{}
+
+func (s *unreliableChainService) ChainId() (*hexutil.Big, error) {
+	return nil, errors.New("internal error")
+}
+
+// netVersionService reports a network id that differs from the chain id, like on
+// Ethereum Classic.
+type netVersionService struct{}
+
+func (s *netVersionService) Version() string { return "1" }
+
+func newChainServer(t *testing.T, service interface{}) *httptest.Server {
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	assert.NoError(t, server.RegisterName("eth", service))
+	assert.NoError(t, server.RegisterName("net", &netVersionService{}))
+	assert.NoError(t, server.RegisterName("web3", &web3Service{}))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return httpServer
+}
+
+func TestSetupPoolRejectsOnlyOtherChains(t *testing.T) {
+	classic := newChainServer(t, &chainService{chainID: 61})
+	mainnet := newChainServer(t, &chainService{chainID: 1})
+	unreliable := newChainServer(t, &unreliableChainService{})
+
+	// net_version is not checked without a NetworkID, so classic is accepted
+	urls := []string{classic.URL, mainnet.URL, unreliable.URL}
+	pool, err := SetupConnectionPoolWithConfig(urls, &PoolConfig{ChainID: big.NewInt(61)})
+	assert.NoError(t, err)
+	defer pool.Close()
+	accepted := make([]string, len(pool.clients))
+	for idx, client := range pool.clients {
+		accepted[idx] = client.url
+	}
+	assert.ElementsMatch(t, []string{classic.URL, unreliable.URL}, accepted)
+	// the unverified endpoint serves no request until its chain is verified
+	for idx, client := range pool.clients {
+		assert.Equal(t, client.url == classic.URL, pool.isHealthy(idx), client.url)
+	}
+
+	// an explicit NetworkID is checked against net_version
+	_, err = SetupConnectionPoolWithConfig([]string{classic.URL}, &PoolConfig{ChainID: big.NewInt(61), NetworkID: big.NewInt(61)})
+	assert.Error(t, err)
+}
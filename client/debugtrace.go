@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Built-in tracers of the debug namespace.
+const (
+	CallTracer     = "callTracer"
+	PrestateTracer = "prestateTracer"
+	FourByteTracer = "4byteTracer"
+)
+
+// TraceConfig holds the options of debug_traceTransaction and debug_traceBlockByNumber.
+// Tracer is either the name of a built-in tracer or the source code of a JS tracer.
+type TraceConfig struct {
+	Tracer       string          `json:"tracer,omitempty"`
+	TracerConfig json.RawMessage `json:"tracerConfig,omitempty"`
+	Timeout      string          `json:"timeout,omitempty"`
+	Reexec       *uint64         `json:"reexec,omitempty"`
+}
+
+// CallTracerConfig holds the options of the built-in callTracer.
+type CallTracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall,omitempty"` // only trace the top-level call
+	WithLog     bool `json:"withLog,omitempty"`     // include the logs emitted by each call
+}
+
+// PrestateTracerConfig holds the options of the built-in prestateTracer.
+type PrestateTracerConfig struct {
+	DiffMode bool `json:"diffMode"` // report the state modifications instead of the prestate
+}
+
+// TxTraceResult is the trace result of a single transaction of a traced block.
+type TxTraceResult struct {
+	TxHash common.Hash     `json:"txHash"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+func newTraceConfig(tracer string, tracerConfig interface{}) (*TraceConfig, error) {
+	config := &TraceConfig{Tracer: tracer}
+	if tracerConfig != nil {
+		raw, err := json.Marshal(tracerConfig)
+		if err != nil {
+			return nil, err
+		}
+		config.TracerConfig = raw
+	}
+	return config, nil
+}
+
+func debugTraceTransaction(ctx context.Context, client rpcCaller, txHash common.Hash, config *TraceConfig) (json.RawMessage, error) {
+	var result json.RawMessage
+	if err := client.Call(ctx, &result, "debug_traceTransaction", txHash, config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func debugTraceBlockByNumber(ctx context.Context, client rpcCaller, number *big.Int, config *TraceConfig) ([]*TxTraceResult, error) {
+	var result []*TxTraceResult
+	if err := client.Call(ctx, &result, "debug_traceBlockByNumber", toBlockNumArg(number), config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// traceTransactionAs traces the transaction using a built-in tracer and decodes the
+// trace into the given result.
+func traceTransactionAs(ctx context.Context, client rpcCaller, txHash common.Hash, tracer string, tracerConfig interface{}, result interface{}) error {
+	config, err := newTraceConfig(tracer, tracerConfig)
+	if err != nil {
+		return err
+	}
+	raw, err := debugTraceTransaction(ctx, client, txHash, config)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, result)
+}
+
+// traceBlockAs traces all transactions of the block using a built-in tracer and decodes
+// the trace of each transaction into T.
+func traceBlockAs[T any](ctx context.Context, client rpcCaller, number *big.Int, tracer string, tracerConfig interface{}) ([]T, error) {
+	config, err := newTraceConfig(tracer, tracerConfig)
+	if err != nil {
+		return nil, err
+	}
+	traces, err := debugTraceBlockByNumber(ctx, client, number, config)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]T, len(traces))
+	for idx, trace := range traces {
+		if trace.Error != "" {
+			return nil, fmt.Errorf("failed to trace transaction %d %s: %s", idx, trace.TxHash, trace.Error)
+		}
+		if err := json.Unmarshal(trace.Result, &results[idx]); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func callTraceTransaction(ctx context.Context, client rpcCaller, txHash common.Hash, config *CallTracerConfig) (*types.CallFrame, error) {
+	if config == nil {
+		config = &CallTracerConfig{}
+	}
+	var result *types.CallFrame
+	if err := traceTransactionAs(ctx, client, txHash, CallTracer, config, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func callTraceBlock(ctx context.Context, client rpcCaller, number *big.Int, config *CallTracerConfig) ([]*types.CallFrame, error) {
+	if config == nil {
+		config = &CallTracerConfig{}
+	}
+	return traceBlockAs[*types.CallFrame](ctx, client, number, CallTracer, config)
+}
+
+func prestateTraceTransaction(ctx context.Context, client rpcCaller, txHash common.Hash) (types.PrestateResult, error) {
+	var result types.PrestateResult
+	if err := traceTransactionAs(ctx, client, txHash, PrestateTracer, nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func stateDiffTraceTransaction(ctx context.Context, client rpcCaller, txHash common.Hash) (*types.PrestateDiff, error) {
+	var result *types.PrestateDiff
+	if err := traceTransactionAs(ctx, client, txHash, PrestateTracer, &PrestateTracerConfig{DiffMode: true}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func fourByteTraceTransaction(ctx context.Context, client rpcCaller, txHash common.Hash) (types.FourByteResult, error) {
+	var result types.FourByteResult
+	if err := traceTransactionAs(ctx, client, txHash, FourByteTracer, nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (ec *ETHClient) DebugTraceTransaction(ctx context.Context, txHash common.Hash, config *TraceConfig) (json.RawMessage, error) {
+	return debugTraceTransaction(ctx, ec, txHash, config)
+}
+
+func (ec *ETHClient) DebugTraceBlockByNumber(ctx context.Context, number *big.Int, config *TraceConfig) ([]*TxTraceResult, error) {
+	return debugTraceBlockByNumber(ctx, ec, number, config)
+}
+
+func (ec *ETHClient) CallTraceTransaction(ctx context.Context, txHash common.Hash, config *CallTracerConfig) (*types.CallFrame, error) {
+	return callTraceTransaction(ctx, ec, txHash, config)
+}
+
+func (ec *ETHClient) CallTraceBlock(ctx context.Context, number *big.Int, config *CallTracerConfig) ([]*types.CallFrame, error) {
+	return callTraceBlock(ctx, ec, number, config)
+}
+
+func (ec *ETHClient) PrestateTraceTransaction(ctx context.Context, txHash common.Hash) (types.PrestateResult, error) {
+	return prestateTraceTransaction(ctx, ec, txHash)
+}
+
+func (ec *ETHClient) PrestateTraceBlock(ctx context.Context, number *big.Int) ([]types.PrestateResult, error) {
+	return traceBlockAs[types.PrestateResult](ctx, ec, number, PrestateTracer, nil)
+}
+
+func (ec *ETHClient) StateDiffTraceTransaction(ctx context.Context, txHash common.Hash) (*types.PrestateDiff, error) {
+	return stateDiffTraceTransaction(ctx, ec, txHash)
+}
+
+func (ec *ETHClient) StateDiffTraceBlock(ctx context.Context, number *big.Int) ([]*types.PrestateDiff, error) {
+	return traceBlockAs[*types.PrestateDiff](ctx, ec, number, PrestateTracer, &PrestateTracerConfig{DiffMode: true})
+}
+
+func (ec *ETHClient) FourByteTraceTransaction(ctx context.Context, txHash common.Hash) (types.FourByteResult, error) {
+	return fourByteTraceTransaction(ctx, ec, txHash)
+}
+
+func (ec *ETHClient) FourByteTraceBlock(ctx context.Context, number *big.Int) ([]types.FourByteResult, error) {
+	return traceBlockAs[types.FourByteResult](ctx, ec, number, FourByteTracer, nil)
+}
+
+func (p *RpcConnectionPool) DebugTraceTransaction(ctx context.Context, txHash common.Hash, config *TraceConfig) (json.RawMessage, error) {
+	return debugTraceTransaction(ctx, p, txHash, config)
+}
+
+func (p *RpcConnectionPool) DebugTraceBlockByNumber(ctx context.Context, number *big.Int, config *TraceConfig) ([]*TxTraceResult, error) {
+	return debugTraceBlockByNumber(ctx, p, number, config)
+}
+
+func (p *RpcConnectionPool) CallTraceTransaction(ctx context.Context, txHash common.Hash, config *CallTracerConfig) (*types.CallFrame, error) {
+	return callTraceTransaction(ctx, p, txHash, config)
+}
+
+func (p *RpcConnectionPool) CallTraceBlock(ctx context.Context, number *big.Int, config *CallTracerConfig) ([]*types.CallFrame, error) {
+	return callTraceBlock(ctx, p, number, config)
+}
+
+func (p *RpcConnectionPool) PrestateTraceTransaction(ctx context.Context, txHash common.Hash) (types.PrestateResult, error) {
+	return prestateTraceTransaction(ctx, p, txHash)
+}
+
+func (p *RpcConnectionPool) PrestateTraceBlock(ctx context.Context, number *big.Int) ([]types.PrestateResult, error) {
+	return traceBlockAs[types.PrestateResult](ctx, p, number, PrestateTracer, nil)
+}
+
+func (p *RpcConnectionPool) StateDiffTraceTransaction(ctx context.Context, txHash common.Hash) (*types.PrestateDiff, error) {
+	return stateDiffTraceTransaction(ctx, p, txHash)
+}
+
+func (p *RpcConnectionPool) StateDiffTraceBlock(ctx context.Context, number *big.Int) ([]*types.PrestateDiff, error) {
+	return traceBlockAs[*types.PrestateDiff](ctx, p, number, PrestateTracer, &PrestateTracerConfig{DiffMode: true})
+}
+
+func (p *RpcConnectionPool) FourByteTraceTransaction(ctx context.Context, txHash common.Hash) (types.FourByteResult, error) {
+	return fourByteTraceTransaction(ctx, p, txHash)
+}
+
+func (p *RpcConnectionPool) FourByteTraceBlock(ctx context.Context, number *big.Int) ([]types.FourByteResult, error) {
+	return traceBlockAs[types.FourByteResult](ctx, p, number, FourByteTracer, nil)
+}
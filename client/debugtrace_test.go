@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCallFrame = `{"type":"CALL","from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","gas":"0x5208","gasUsed":"0x5208","input":"0x","value":"0x1"}`
+
+// tracerService serves the debug namespace, it records the trace config of the last
+// request and fails the trace of the transactions listed in failed.
+type tracerService struct {
+	config map[string]interface{}
+	failed map[int]string
+}
+
+func (s *tracerService) TraceTransaction(hash common.Hash, config map[string]interface{}) json.RawMessage {
+	s.config = config
+	return json.RawMessage(testCallFrame)
+}
+
+func (s *tracerService) TraceBlockByNumber(number string, config map[string]interface{}) []map[string]interface{} {
+	s.config = config
+	results := make([]map[string]interface{}, 3)
+	for idx := range results {
+		results[idx] = map[string]interface{}{"txHash": common.BigToHash(big.NewInt(int64(idx)))}
+		if reason, ok := s.failed[idx]; ok {
+			results[idx]["error"] = reason
+		} else {
+			results[idx]["result"] = json.RawMessage(testCallFrame)
+		}
+	}
+	return results
+}
+
+func TestCallTraceTransaction(t *testing.T) {
+	service := &tracerService{}
+	ec := newNamespaceTestClient(t, "debug", service)
+	defer ec.Close()
+
+	frame, err := ec.CallTraceTransaction(context.Background(), common.Hash{}, &CallTracerConfig{WithLog: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "CALL", frame.Type)
+	assert.Equal(t, uint64(21000), frame.GasUsed)
+	assert.Equal(t, map[string]interface{}{
+		"tracer":       "callTracer",
+		"tracerConfig": map[string]interface{}{"withLog": true},
+	}, service.config)
+}
+
+func TestCallTraceBlock(t *testing.T) {
+	service := &tracerService{}
+	ec := newNamespaceTestClient(t, "debug", service)
+	defer ec.Close()
+
+	frames, err := ec.CallTraceBlock(context.Background(), big.NewInt(16), nil)
+	assert.NoError(t, err)
+	assert.Len(t, frames, 3)
+	assert.Equal(t, "callTracer", service.config["tracer"])
+
+	// the trace of the block fails with the first failed transaction
+	service.failed = map[int]string{1: "execution timeout"}
+	_, err = ec.CallTraceBlock(context.Background(), big.NewInt(16), nil)
+	assert.ErrorContains(t, err, "failed to trace transaction 1 "+common.BigToHash(big.NewInt(1)).Hex()+": execution timeout")
+}
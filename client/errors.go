@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Error classes of failed requests, use errors.Is to check the class of an error returned
+// by ETHClient or RpcConnectionPool.
+var (
+	ErrTransport         = errors.New("transport error")
+	ErrTimeout           = errors.New("request timeout")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrMethodNotFound    = errors.New("method not found")
+	ErrInvalidParams     = errors.New("invalid params")
+	ErrExecutionReverted = errors.New("execution reverted")
+	ErrHeaderNotFound    = errors.New("header not found") // the endpoint does not have the block or its state
+	ErrInternal          = errors.New("internal error")
+)
+
+const (
+	rpcInvalidParamsCode     = -32602
+	rpcInternalErrorCode     = -32603
+	rpcExecutionRevertedCode = 3
+)
+
+// headerNotFoundErrors contains the error messages of the major clients when the block or
+// its state is not available, either not synced yet or pruned.
+var headerNotFoundErrors = []string{
+	"header not found",
+	"unknown block",
+	"block not found",
+	"missing trie node",
+	"state not available",
+	"state is not available",
+	"historical state",
+	"required historical state unavailable",
+}
+
+// domainErrors contains the error messages of the major clients rejecting a request for
+// reasons every other endpoint would reject it for too, mostly transaction pool checks
+// besides the known transaction and nonce too low errors.
+var domainErrors = []string{
+	"nonce too high",
+	"insufficient funds",
+	"transaction underpriced",
+	"intrinsic gas too low",
+	"exceeds block gas limit",
+	"gas required exceeds allowance",
+	"max fee per gas less than block base fee",
+	"max priority fee per gas higher than max fee per gas",
+	"invalid sender",
+	"exceeds the configured cap",
+	"oversized data",
+}
+
+// ClassifiedError is an error of a request tagged with its class. It forwards the code
+// and the data of the wrapped error so that it can be used as an rpc.Error and an
+// rpc.DataError like the errors of rpc.Client.
+type ClassifiedError struct {
+	Class error // one of the Err* classes
+	Err   error
+}
+
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClassifiedError) Unwrap() []error {
+	return []error{e.Class, e.Err}
+}
+
+// ErrorCode returns the code of the wrapped error response, zero if it is not one.
+func (e *ClassifiedError) ErrorCode() int {
+	var rpcErr rpc.Error
+	if errors.As(e.Err, &rpcErr) {
+		return rpcErr.ErrorCode()
+	}
+	return 0
+}
+
+// ErrorData returns the data of the wrapped error response, nil if it has none.
+func (e *ClassifiedError) ErrorData() interface{} {
+	var dataErr rpc.DataError
+	if errors.As(e.Err, &dataErr) {
+		return dataErr.ErrorData()
+	}
+	return nil
+}
+
+func containsAny(msg string, patterns []string) bool {
+	msg = strings.ToLower(msg)
+	for _, pattern := range patterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyError returns the class of the error of a request, or nil if it has none, like
+// the domain errors of the server (nonce too low, insufficient funds...).
+func classifyError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return nil
+	}
+	var classified *ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+	// the query is too large for any endpoint, it is split by the caller
+	if isLogRangeError(err) {
+		return nil
+	}
+	// reverts are checked first, the revert reason may mention anything like rate limits
+	if isRevertError(err) {
+		return ErrExecutionReverted
+	}
+	if isRateLimitError(err) {
+		return ErrRateLimited
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch {
+		case httpErr.StatusCode == http.StatusRequestTimeout || httpErr.StatusCode == http.StatusGatewayTimeout:
+			return ErrTimeout
+		default:
+			return ErrTransport
+		}
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		switch {
+		case isMethodNotFoundError(err):
+			return ErrMethodNotFound
+		case containsAny(err.Error(), headerNotFoundErrors):
+			return ErrHeaderNotFound
+		case rpcErr.ErrorCode() == rpcInvalidParamsCode:
+			return ErrInvalidParams
+		case rpcErr.ErrorCode() == rpcInternalErrorCode:
+			return ErrInternal
+		}
+		return nil
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTimeout
+	}
+	var urlErr *url.Error
+	if netErr != nil || errors.As(err, &urlErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, rpc.ErrClientQuit) {
+		return ErrTransport
+	}
+	return nil
+}
+
+// classify tags the error of a request with its class.
+func classify(err error) error {
+	if class := classifyError(err); class != nil {
+		if _, ok := err.(*ClassifiedError); !ok {
+			return &ClassifiedError{Class: class, Err: err}
+		}
+	}
+	return err
+}
+
+// isRevertError reports whether err is the error response of a reverted call.
+func isRevertError(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	return rpcErr.ErrorCode() == rpcExecutionRevertedCode || containsAny(err.Error(), []string{"revert"})
+}
+
+// isDomainError reports whether err is a domain error of the server that the other
+// endpoints would return the same way, like nonce too low.
+func isDomainError(err error) bool {
+	return isRPCError(err) && (containsAny(err.Error(), domainErrors) || isKnownTxError(err) || isNonceTooLowError(err))
+}
+
+// isOutage reports whether the error class means the endpoint is unavailable and should
+// be put into cooldown.
+func isOutage(class error) bool {
+	return class == ErrTransport || class == ErrTimeout || class == ErrInternal
+}
+
+// isCallerError reports whether the error class means the request itself is wrong, the
+// other endpoints would fail it the same way.
+func isCallerError(class error) bool {
+	return class == ErrInvalidParams || class == ErrExecutionReverted
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRPCError struct {
+	code int
+	msg  string
+}
+
+func (e *testRPCError) Error() string  { return e.msg }
+func (e *testRPCError) ErrorCode() int { return e.code }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err   error
+		class error
+	}{
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrTransport},
+		{io.ErrUnexpectedEOF, ErrTransport},
+		{rpc.HTTPError{StatusCode: 502, Status: "502 Bad Gateway"}, ErrTransport},
+		{rpc.HTTPError{StatusCode: 504, Status: "504 Gateway Timeout"}, ErrTimeout},
+		{rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"}, ErrRateLimited},
+		{context.DeadlineExceeded, ErrTimeout},
+		{&testRPCError{-32005, "project ID request rate exceeded"}, ErrRateLimited},
+		{&testRPCError{-32601, "the method eth_foo does not exist/is not available"}, ErrMethodNotFound},
+		{&testRPCError{-32602, "invalid argument 0: hex string without 0x prefix"}, ErrInvalidParams},
+		{&testRPCError{3, "execution reverted: not owner"}, ErrExecutionReverted},
+		{&testRPCError{-32000, "execution reverted"}, ErrExecutionReverted},
+		{&testRPCError{3, "execution reverted: rate limit exceeded"}, ErrExecutionReverted},
+		{&testRPCError{-32000, "header not found"}, ErrHeaderNotFound},
+		{&testRPCError{-32000, "missing trie node 1a2b (path )"}, ErrHeaderNotFound},
+		{&testRPCError{-32603, "internal error"}, ErrInternal},
+		{&testRPCError{-32000, "nonce too low"}, nil},
+		{ethereum.NotFound, nil},
+		{context.Canceled, nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.class, classifyError(tt.err), tt.err.Error())
+		if tt.class != nil {
+			err := fmt.Errorf("request failed: %w", classify(tt.err))
+			assert.ErrorIs(t, err, tt.class)
+			var classified *ClassifiedError
+			assert.ErrorAs(t, err, &classified)
+			assert.Equal(t, tt.err, classified.Err)
+		}
+	}
+}
+
+// revertingService reverts every eth_call.
+type revertingService struct {
+	calls int
+}
+
+func (s *revertingService) Call(args map[string]interface{}, number string) (hexutil.Bytes, error) {
+	s.calls++
+	return nil, &testRPCError{3, "execution reverted"}
+}
+
+func TestPoolDoesNotFailOverCallerErrors(t *testing.T) {
+	first, second := &revertingService{}, &revertingService{}
+	pool := NewRpcConnectionPool([]*ETHClient{
+		newTestClient(t, first, Capabilities{}),
+		newTestClient(t, second, Capabilities{}),
+	})
+	defer pool.Close()
+
+	_, err := pool.CallContract(context.Background(), ethereum.CallMsg{}, big.NewInt(0).SetInt64(-1))
+	assert.ErrorIs(t, err, ErrExecutionReverted)
+	assert.Equal(t, 1, first.calls+second.calls)
+	assert.Equal(t, clientStatusActive, pool.status[0])
+	assert.Equal(t, clientStatusActive, pool.status[1])
+}
+
+// flakyGatewayService fails eth_gasPrice with a generic server error of a gateway.
+type flakyGatewayService struct {
+	calls int
+}
+
+func (s *flakyGatewayService) GasPrice() (*hexutil.Big, error) {
+	s.calls++
+	return nil, &testRPCError{-32000, "upstream error"}
+}
+
+// nonceTooLowService rejects every transaction with nonce too low.
+type nonceTooLowService struct {
+	calls int
+}
+
+func (s *nonceTooLowService) SendRawTransaction(tx hexutil.Bytes) (common.Hash, error) {
+	s.calls++
+	return common.Hash{}, &testRPCError{-32000, "nonce too low"}
+}
+
+func TestPoolFailsOverServerErrors(t *testing.T) {
+	gateway := &flakyGatewayService{}
+	pool := NewRpcConnectionPool([]*ETHClient{
+		newTestClient(t, gateway, Capabilities{}),
+		newTestClient(t, &limitedGasService{calls: 1}, Capabilities{}),
+	})
+	defer pool.Close()
+
+	price, err := pool.SuggestGasPrice(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), price)
+	assert.Equal(t, 1, gateway.calls)
+	assert.Equal(t, clientStatusActive, pool.status[0])
+
+	// domain errors are returned by the first client
+	first, second := &nonceTooLowService{}, &nonceTooLowService{}
+	pool = NewRpcConnectionPool([]*ETHClient{
+		newTestClient(t, first, Capabilities{}),
+		newTestClient(t, second, Capabilities{}),
+	})
+	defer pool.Close()
+	err = pool.Call(context.Background(), nil, "eth_sendRawTransaction", hexutil.Bytes{0x01})
+	assert.Error(t, err)
+	assert.Equal(t, 1, first.calls+second.calls)
+}
+
+// rateLimitRevertService reverts every eth_call with a reason mentioning a rate limit.
+type rateLimitRevertService struct {
+	calls int
+}
+
+func (s *rateLimitRevertService) Call(args map[string]interface{}, number string) (hexutil.Bytes, error) {
+	s.calls++
+	return nil, &testRPCError{3, "execution reverted: rate limit exceeded"}
+}
+
+func TestPoolReturnsRevertMentioningRateLimit(t *testing.T) {
+	service := &rateLimitRevertService{}
+	pool := NewRpcConnectionPool([]*ETHClient{newTestClient(t, service, Capabilities{})})
+	defer pool.Close()
+
+	_, err := pool.CallContract(context.Background(), ethereum.CallMsg{}, nil)
+	_, ok := err.(*RevertError)
+	assert.True(t, ok)
+	assert.Equal(t, 1, service.calls)
+	assert.LessOrEqual(t, pool.throttleDelay(0, time.Now()), time.Duration(0))
+}
+
+func TestClassifiedErrorForwardsCodeAndData(t *testing.T) {
+	err := classify(&revertDataError{"0x01"})
+	rpcErr, ok := err.(rpc.Error)
+	assert.True(t, ok)
+	assert.Equal(t, 3, rpcErr.ErrorCode())
+	dataErr, ok := err.(rpc.DataError)
+	assert.True(t, ok)
+	assert.Equal(t, "0x01", dataErr.ErrorData())
+	assert.False(t, isRPCError(classify(io.ErrUnexpectedEOF)))
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/khanghh/ethcore/types"
@@ -13,6 +16,7 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -27,6 +31,11 @@ type ETHClient struct {
 	networkId string
 	version   string
 	latency   time.Duration
+
+	caps               atomic.Pointer[Capabilities]
+	capsProbed         atomic.Bool  // all capabilities were probed conclusively
+	unsupportedMethods sync.Map     // methods the endpoint reported as not found
+	retryAfter         atomic.Int64 // last Retry-After duration sent by the endpoint
 }
 
 func (ec *ETHClient) Url() string {
@@ -34,7 +43,16 @@ func (ec *ETHClient) Url() string {
 }
 
 func (ec *ETHClient) connect(ctx context.Context) error {
-	client, err := rpc.DialContext(ctx, ec.url)
+	var (
+		client *rpc.Client
+		err    error
+	)
+	if supportsSubscriptions(ec.url) {
+		client, err = rpc.DialContext(ctx, ec.url)
+	} else {
+		transport := &retryAfterTransport{base: http.DefaultTransport, retryAfter: &ec.retryAfter}
+		client, err = rpc.DialHTTPWithClient(ec.url, &http.Client{Transport: transport})
+	}
 	if err != nil {
 		return err
 	}
@@ -47,6 +65,13 @@ func (ec *ETHClient) connect(ctx context.Context) error {
 		return err
 	}
 	ec.client = client
+	// the capabilities of an endpoint are probed until the probes are conclusive,
+	// reconnects keep them afterwards
+	if !ec.capsProbed.Load() {
+		caps, conclusive := ec.probeCapabilities(ctx)
+		ec.caps.Store(caps)
+		ec.capsProbed.Store(conclusive)
+	}
 	return nil
 }
 
@@ -59,13 +84,25 @@ func (ec *ETHClient) ClientVersion() string {
 }
 
 func (ec *ETHClient) BlockByHash(ctx context.Context, hash common.Hash, fullBlock bool) (block *types.Block, reqErr error) {
-	return getBlock(ctx, ec, "eth_getBlockByNumber", hash, fullBlock)
+	return getBlock(ctx, ec, "eth_getBlockByHash", hash, fullBlock)
 }
 
 func (ec *ETHClient) BlockByNumber(ctx context.Context, number *big.Int, fullBlock bool) (*types.Block, error) {
 	return getBlock(ctx, ec, "eth_getBlockByNumber", toBlockNumArg(number), fullBlock)
 }
 
+func (ec *ETHClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return headerByHash(ctx, ec, hash)
+}
+
+func (ec *ETHClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return headerByNumber(ctx, ec, number)
+}
+
+func (ec *ETHClient) HeadersByRange(ctx context.Context, from, to uint64) ([]*types.Header, error) {
+	return headersByRange(ctx, ec, from, to)
+}
+
 func (ec *ETHClient) BlockNumber(ctx context.Context) (*big.Int, error) {
 	var result string
 	if err := ec.client.CallContext(ctx, &result, "eth_blockNumber"); err != nil {
@@ -75,13 +112,7 @@ func (ec *ETHClient) BlockNumber(ctx context.Context) (*big.Int, error) {
 }
 
 func (ec *ETHClient) BlockReceipts(ctx context.Context, numberOrHash interface{}) (types.Receipts, error) {
-	numberOrHashArg, err := parseNumberOrHash(numberOrHash)
-	if err != nil {
-		return nil, err
-	}
-	var result types.Receipts
-	err = ec.Call(ctx, &result, "eth_getBlockReceipts", numberOrHashArg)
-	return result, err
+	return ec.blockReceipts(ctx, numberOrHash)
 }
 
 func (ec *ETHClient) CodeAt(ctx context.Context, account common.Address, number *big.Int) ([]byte, error) {
@@ -94,6 +125,14 @@ func (ec *ETHClient) Close() {
 	ec.client.Close()
 }
 
+func (ec *ETHClient) ChainID(ctx context.Context) (*big.Int, error) {
+	var result hexutil.Big
+	if err := ec.Call(ctx, &result, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&result), nil
+}
+
 func (ec *ETHClient) NetworkID(ctx context.Context) (*big.Int, error) {
 	version := new(big.Int)
 	var ver string
@@ -127,19 +166,91 @@ func (ec *ETHClient) TransactionReceipt(ctx context.Context, txHash common.Hash)
 	return receipt, err
 }
 
+func (ec *ETHClient) BalanceAt(ctx context.Context, account common.Address, number *big.Int) (*big.Int, error) {
+	return balanceAt(ctx, ec, account, number)
+}
+
+func (ec *ETHClient) BalancesAt(ctx context.Context, accounts []common.Address, number *big.Int) ([]*big.Int, error) {
+	return balancesAt(ctx, ec, accounts, number)
+}
+
+func (ec *ETHClient) NonceAt(ctx context.Context, account common.Address, number *big.Int) (uint64, error) {
+	return nonceAt(ctx, ec, account, number)
+}
+
+func (ec *ETHClient) NoncesAt(ctx context.Context, accounts []common.Address, number *big.Int) ([]uint64, error) {
+	return noncesAt(ctx, ec, accounts, number)
+}
+
+func (ec *ETHClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return pendingNonceAt(ctx, ec, account)
+}
+
+func (ec *ETHClient) StorageAt(ctx context.Context, account common.Address, key common.Hash, number *big.Int) ([]byte, error) {
+	return storageAt(ctx, ec, account, key, number)
+}
+
+func (ec *ETHClient) StoragesAt(ctx context.Context, account common.Address, keys []common.Hash, number *big.Int) ([][]byte, error) {
+	return storagesAt(ctx, ec, account, keys, number)
+}
+
+func (ec *ETHClient) ProofAt(ctx context.Context, account common.Address, storageKeys []common.Hash, number *big.Int) (*AccountProof, error) {
+	return getProof(ctx, ec, account, storageKeys, number)
+}
+
+func (ec *ETHClient) VerifiedProofAt(ctx context.Context, account common.Address, storageKeys []common.Hash, number *big.Int) (*AccountProof, error) {
+	return getVerifiedProof(ctx, ec, account, storageKeys, number)
+}
+
 func (ec *ETHClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
 	var hex hexutil.Bytes
 	err := ec.Call(ctx, &hex, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
 	if err != nil {
-		return nil, err
+		return nil, toRevertError(err)
 	}
 	return hex, nil
 }
 
+func (ec *ETHClient) CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, overrides StateOverride, blockOverrides *BlockOverrides) ([]byte, error) {
+	return callContractWithOverrides(ctx, ec, msg, blockNumber, overrides, blockOverrides)
+}
+
+func (ec *ETHClient) CreateAccessList(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (*types.AccessList, uint64, string, error) {
+	return createAccessList(ctx, ec, msg, blockNumber)
+}
+
+func (ec *ETHClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) (types.Logs, error) {
+	return filterLogs(ctx, ec, q)
+}
+
+func (ec *ETHClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return estimateGas(ctx, ec, msg)
+}
+
+func (ec *ETHClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return suggestGasPrice(ctx, ec)
+}
+
+func (ec *ETHClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return suggestGasTipCap(ctx, ec)
+}
+
+func (ec *ETHClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return feeHistory(ctx, ec, blockCount, lastBlock, rewardPercentiles)
+}
+
+func (ec *ETHClient) SendTransaction(ctx context.Context, tx *gethtypes.Transaction) error {
+	return sendTransaction(ctx, ec, tx)
+}
+
+func (ec *ETHClient) SendRawTransaction(ctx context.Context, rawTx []byte) (common.Hash, error) {
+	return sendRawTransaction(ctx, ec, rawTx)
+}
+
 func (ec *ETHClient) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
 	err := ec.client.CallContext(ctx, result, method, params...)
 	log.Debug("Request RPC call", "url", ec.url, "method", method, "params", params, "result", map[bool]string{true: "OK", false: fmt.Sprint(err)}[err == nil])
-	return err
+	return classify(err)
 }
 
 func (ec *ETHClient) BatchCall(ctx context.Context, batch []rpc.BatchElem) error {
@@ -149,7 +260,10 @@ func (ec *ETHClient) BatchCall(ctx context.Context, batch []rpc.BatchElem) error
 		methodsMap[elem.Method] = true
 	}
 	log.Debug("Request RPC batch call", "url", ec.url, "count", len(batch), "methods", maps.Keys(methodsMap), "result", map[bool]string{true: "OK", false: fmt.Sprint(err)}[err == nil])
-	return err
+	for idx := range batch {
+		batch[idx].Error = classify(batch[idx].Error)
+	}
+	return classify(err)
 }
 
 func NewClient(client *rpc.Client) *ETHClient {
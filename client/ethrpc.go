@@ -11,6 +11,7 @@ import (
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -18,24 +19,68 @@ import (
 type Error = rpc.Error
 
 type RemoteChainReader interface {
+	// ChainID returns the chain identifier of the remote chain.
+	ChainID(ctx context.Context) (*big.Int, error)
 	// NetworkID returns the network identifier of the remote chain.
 	NetworkID(ctx context.Context) (*big.Int, error)
 	// BlockByHash retrieves a block from the remote chain by hash.
 	BlockByHash(ctx context.Context, hash common.Hash, fullBlock bool) (*types.Block, error)
 	// BlockByNumber retrieves a block from the remote chain by number.
 	BlockByNumber(ctx context.Context, number *big.Int, fullBlock bool) (*types.Block, error)
+	// HeaderByHash retrieves a block header from the remote chain by hash.
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	// HeaderByNumber retrieves a block header from the remote chain by number.
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	// HeadersByRange retrieves the block headers of the given range of blocks using batch requests.
+	HeadersByRange(ctx context.Context, from, to uint64) ([]*types.Header, error)
 	// BlockNumber retrieves the current block number of the remote chain.
 	BlockNumber(ctx context.Context) (*big.Int, error)
 	// GetTransactionByHash retrieves a transaction by its hash, also returns the block hash, block number, transaction index in block
 	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
 	// TransactionReceipt retrieves the receipts of a transaction by its hash.
 	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
-	// BlockReceipts retrieves the receipts of a block by its hash.
+	// BlockReceipts retrieves the receipts of a block by its number or hash.
 	BlockReceipts(ctx context.Context, numberOrHash interface{}) (types.Receipts, error)
 	// CodeAt retrieves the contract code of the given account in the given block.
 	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	// BalanceAt returns the wei balance of the given account in the given block.
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	// BalancesAt returns the wei balances of the given accounts in the given block using batch requests.
+	BalancesAt(ctx context.Context, accounts []common.Address, blockNumber *big.Int) ([]*big.Int, error)
+	// NonceAt returns the account nonce of the given account in the given block.
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	// NoncesAt returns the account nonces of the given accounts in the given block using batch requests.
+	NoncesAt(ctx context.Context, accounts []common.Address, blockNumber *big.Int) ([]uint64, error)
+	// PendingNonceAt returns the account nonce of the given account in the pending state.
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	// StorageAt returns the value of key in the contract storage of the given account in the given block.
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+	// StoragesAt returns the values of keys in the contract storage of the given account using batch requests.
+	StoragesAt(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) ([][]byte, error)
+	// ProofAt returns the Merkle-Patricia proof of the given account and storage keys in the given block.
+	ProofAt(ctx context.Context, account common.Address, storageKeys []common.Hash, blockNumber *big.Int) (*AccountProof, error)
+	// VerifiedProofAt returns the account proof after verifying it against the state root of the given block.
+	VerifiedProofAt(ctx context.Context, account common.Address, storageKeys []common.Hash, blockNumber *big.Int) (*AccountProof, error)
 	// CallContract executes a contract call with the given parameters.
 	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	// CallContractWithOverrides executes a contract call on top of the overridden state and block context.
+	CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, overrides StateOverride, blockOverrides *BlockOverrides) ([]byte, error)
+	// CreateAccessList creates the access list of the given call, it also returns the gas used and the execution error.
+	CreateAccessList(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (*types.AccessList, uint64, string, error)
+	// FilterLogs executes a filter query, large block ranges are split automatically.
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) (types.Logs, error)
+	// EstimateGas estimates the gas needed to execute the given call against the pending state.
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	// SuggestGasPrice retrieves the currently suggested gas price for legacy transactions.
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	// SuggestGasTipCap retrieves the currently suggested gas tip cap for EIP-1559 transactions.
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	// FeeHistory retrieves the fee market history of the given range of blocks.
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+	// SendTransaction injects a signed transaction into the pending pool for execution.
+	SendTransaction(ctx context.Context, tx *gethtypes.Transaction) error
+	// SendRawTransaction injects a signed RLP encoded transaction into the pending pool for execution.
+	SendRawTransaction(ctx context.Context, rawTx []byte) (common.Hash, error)
 	// Call executes an RPC call with the given method and arguments.
 	Call(ctx context.Context, result interface{}, method string, args ...interface{}) error
 	// BatchCall executes a batch of RPC calls.
@@ -58,7 +103,15 @@ func DialContext(ctx context.Context, url string) (*ETHClient, error) {
 }
 
 func SetupConnectionPool(urls []string) (*RpcConnectionPool, error) {
+	return SetupConnectionPoolWithConfig(urls, nil)
+}
+
+// SetupConnectionPoolWithConfig connects to the endpoints and creates a pool of the
+// reachable ones. If the config has a chain id, endpoints of other chains are rejected and
+// the endpoints whose chain could not be verified are only used once it is.
+func SetupConnectionPoolWithConfig(urls []string, config *PoolConfig) (*RpcConnectionPool, error) {
 	clients := []*ETHClient{}
+	unverified := make(map[*ETHClient]bool)
 	lock := sync.Mutex{}
 	sem := make(chan struct{}, 5)
 	wg := sync.WaitGroup{}
@@ -77,9 +130,23 @@ func SetupConnectionPool(urls []string) (*RpcConnectionPool, error) {
 				log.Debug("Could not establish connection to RPC endpoint", "url", url, "err", err)
 				return
 			}
+			var verifyErr error
+			if config != nil && config.ChainID != nil {
+				// only a mismatch rejects the endpoint, the others are admitted in cooldown
+				// until their chain is verified
+				verifyErr = client.verifyChain(ctx, config.ChainID, config.NetworkID)
+				if _, mismatch := verifyErr.(*ChainMismatchError); mismatch {
+					log.Warn("Rejected RPC endpoint", "url", url, "err", verifyErr)
+					client.Close()
+					return
+				} else if verifyErr != nil {
+					log.Warn("Could not verify chain of RPC endpoint", "url", url, "err", verifyErr)
+				}
+			}
 			log.Info("Connected to RPC endpoint", "url", url, "version", client.ClientVersion(), "latency", client.Latency())
 			lock.Lock()
 			clients = append(clients, client)
+			unverified[client] = verifyErr != nil
 			lock.Unlock()
 		}(url)
 	}
@@ -88,7 +155,13 @@ func SetupConnectionPool(urls []string) (*RpcConnectionPool, error) {
 		sort.Slice(clients, func(i, j int) bool {
 			return clients[i].Latency() < clients[j].Latency()
 		})
-		return NewRpcConnectionPool(clients), nil
+		pool := newRpcConnectionPool(clients, config)
+		for idx, client := range clients {
+			if unverified[client] {
+				pool.suspend(idx, client)
+			}
+		}
+		return pool, nil
 	}
 	return nil, fmt.Errorf("no connection established")
 }
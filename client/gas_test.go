@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// feeService records the call arguments of eth_estimateGas and serves fixed fees.
+type feeService struct {
+	args map[string]interface{}
+}
+
+func (s *feeService) EstimateGas(args map[string]interface{}) (hexutil.Uint64, error) {
+	s.args = args
+	if _, ok := args["value"]; ok {
+		return 0, &testRPCError{3, "execution reverted"}
+	}
+	return 21000, nil
+}
+
+func (s *feeService) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(30))
+}
+
+func (s *feeService) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(2))
+}
+
+func (s *feeService) FeeHistory(blockCount hexutil.Uint, lastBlock string, percentiles []float64) map[string]interface{} {
+	return map[string]interface{}{
+		"oldestBlock":   "0x63",
+		"reward":        [][]string{{"0x1", "0x2"}, {"0x3", "0x4"}},
+		"baseFeePerGas": []string{"0xa", "0xb", "0xc"},
+		"gasUsedRatio":  []float64{0.5, 0.25},
+	}
+}
+
+func TestEstimateGasCallArgs(t *testing.T) {
+	service := &feeService{}
+	ec := newTestClient(t, service, Capabilities{})
+	defer ec.Close()
+
+	to := common.HexToAddress("0x01")
+	msg := ethereum.CallMsg{
+		From:      common.HexToAddress("0x02"),
+		To:        &to,
+		GasFeeCap: big.NewInt(100),
+		GasTipCap: big.NewInt(2),
+		AccessList: gethtypes.AccessList{
+			{Address: to, StorageKeys: []common.Hash{common.HexToHash("0x03")}},
+		},
+	}
+	gas, err := ec.EstimateGas(context.Background(), msg)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(21000), gas)
+	assert.Equal(t, "0x64", service.args["maxFeePerGas"])
+	assert.Equal(t, "0x2", service.args["maxPriorityFeePerGas"])
+	assert.NotContains(t, service.args, "gasPrice")
+	assert.NotContains(t, service.args, "gas")
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"address":     to.Hex(),
+		"storageKeys": []interface{}{common.HexToHash("0x03").Hex()},
+	}}, service.args["accessList"])
+
+	msg = ethereum.CallMsg{To: &to, GasPrice: big.NewInt(30), Gas: 50000, Value: big.NewInt(1)}
+	_, err = ec.EstimateGas(context.Background(), msg)
+	assert.ErrorIs(t, err, ErrExecutionReverted)
+	assert.Equal(t, "0x1e", service.args["gasPrice"])
+	assert.Equal(t, "0xc350", service.args["gas"])
+	assert.NotContains(t, service.args, "maxFeePerGas")
+	assert.NotContains(t, service.args, "accessList")
+}
+
+func TestSuggestFees(t *testing.T) {
+	ec := newTestClient(t, &feeService{}, Capabilities{})
+	defer ec.Close()
+
+	price, err := ec.SuggestGasPrice(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(30), price)
+	tip, err := ec.SuggestGasTipCap(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(2), tip)
+
+	history, err := ec.FeeHistory(context.Background(), 2, nil, []float64{25, 75})
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(99), history.OldestBlock)
+	assert.Equal(t, [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3), big.NewInt(4)}}, history.Reward)
+	assert.Equal(t, []*big.Int{big.NewInt(10), big.NewInt(11), big.NewInt(12)}, history.BaseFee)
+	assert.Equal(t, []float64{0.5, 0.25}, history.GasUsedRatio)
+}
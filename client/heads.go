@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	rpcHeadPollInterval = 5 * time.Second
+	defaultMaxHeadLag   = 5
+)
+
+// errNullResult is returned by the pool when a client has no result for a request that
+// another client may be able to serve.
+var errNullResult = errors.New("null result")
+
+// nullRetryMethods are the lookups retried on other clients when a client returns null,
+// load balanced endpoints often serve them from a backend that is not synced yet.
+var nullRetryMethods = map[string]bool{
+	"eth_getBlockByNumber":      true,
+	"eth_getBlockByHash":        true,
+	"eth_getBlockReceipts":      true,
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionReceipt": true,
+}
+
+func isNullResult(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// bestHead returns the highest block number reported by the healthy clients.
+func (p *RpcConnectionPool) bestHead() uint64 {
+	var best uint64
+	for idx := range p.clients {
+		if head := atomic.LoadUint64(&p.heads[idx]); head > best && p.isHealthy(idx) {
+			best = head
+		}
+	}
+	return best
+}
+
+// isLagging reports whether the client is too far behind the best head to serve the
+// request, clients whose head is not known yet are never lagging.
+func (p *RpcConnectionPool) isLagging(idx int, r route) bool {
+	if !r.latest && r.number == 0 {
+		return false
+	}
+	head := atomic.LoadUint64(&p.heads[idx])
+	if head == 0 || p.bestHead() <= head+p.maxHeadLag {
+		return false
+	}
+	return r.latest || r.number > head
+}
+
+// updateHeads polls the head of every healthy client. Polls are charged to the rate limit
+// of the endpoint, a throttled client keeps its last known head until the next poll.
+func (p *RpcConnectionPool) updateHeads() {
+	var (
+		wg  sync.WaitGroup
+		now = time.Now()
+	)
+	for idx, client := range p.clients {
+		if !p.isHealthy(idx) || p.throttleDelay(idx, now) > 0 {
+			continue
+		}
+		if _, ok := p.takeToken(idx, now); !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int, client *ETHClient) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), rpcRequestTimeout)
+			defer cancel()
+			number, err := client.BlockNumber(ctx)
+			if err != nil {
+				log.Debug("Failed to update RPC endpoint head", "url", client.url, "error", err)
+				return
+			}
+			atomic.StoreUint64(&p.heads[idx], number.Uint64())
+		}(idx, client)
+	}
+	wg.Wait()
+}
+
+func (p *RpcConnectionPool) headLoop() {
+	ticker := time.NewTicker(rpcHeadPollInterval)
+	defer ticker.Stop()
+	for {
+		p.updateHeads()
+		select {
+		case <-p.quitCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
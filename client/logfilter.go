@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// logRangeErrors contains the error messages returned by well-known providers when an
+// eth_getLogs query covers too many blocks or matches too many logs.
+var logRangeErrors = []string{
+	"query returned more than",
+	"block range too large",
+	"block range is too wide",
+	"exceed maximum block range",
+	"range too large",
+	"too many blocks",
+	"log response size exceeded",
+	"response size exceeded",
+	"query timeout exceeded",
+}
+
+// isLogRangeError reports whether err indicates that an eth_getLogs query has to be split
+// into smaller block ranges to succeed.
+func isLogRangeError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range logRangeErrors {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
+	arg := map[string]interface{}{
+		"address": q.Addresses,
+		"topics":  q.Topics,
+	}
+	if q.BlockHash != nil {
+		if q.FromBlock != nil || q.ToBlock != nil {
+			return nil, errors.New("cannot specify both BlockHash and FromBlock/ToBlock")
+		}
+		arg["blockHash"] = *q.BlockHash
+	} else {
+		if q.FromBlock == nil {
+			arg["fromBlock"] = "0x0"
+		} else {
+			arg["fromBlock"] = toBlockNumArg(q.FromBlock)
+		}
+		arg["toBlock"] = toBlockNumArg(q.ToBlock)
+	}
+	return arg, nil
+}
+
+// resolveFilterRange converts the block range of the filter query into absolute block
+// numbers, resolving the latest block from the remote chain if needed.
+func resolveFilterRange(ctx context.Context, client rpcCaller, q ethereum.FilterQuery) (uint64, uint64, error) {
+	var from, to uint64
+	if q.FromBlock != nil {
+		if q.FromBlock.Sign() < 0 {
+			return 0, 0, fmt.Errorf("cannot split log range from %s block", toBlockNumArg(q.FromBlock))
+		}
+		from = q.FromBlock.Uint64()
+	}
+	if q.ToBlock != nil && q.ToBlock.Sign() >= 0 {
+		to = q.ToBlock.Uint64()
+	} else {
+		var head hexutil.Uint64
+		if err := client.Call(ctx, &head, "eth_blockNumber"); err != nil {
+			return 0, 0, err
+		}
+		to = uint64(head)
+	}
+	return from, to, nil
+}
+
+func filterLogs(ctx context.Context, client rpcCaller, q ethereum.FilterQuery) (types.Logs, error) {
+	arg, err := toFilterArg(q)
+	if err != nil {
+		return nil, err
+	}
+	var logs types.Logs
+	err = client.Call(ctx, &logs, "eth_getLogs", arg)
+	if err == nil || q.BlockHash != nil || !isLogRangeError(err) {
+		return logs, err
+	}
+	from, to, resolveErr := resolveFilterRange(ctx, client, q)
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	if from >= to {
+		return nil, err
+	}
+	return splitFilterLogs(ctx, client, q, from, to)
+}
+
+// filterLogsInRange queries logs in the block range [from, to], the range is split in
+// two halves and retried recursively if the provider rejects it.
+func filterLogsInRange(ctx context.Context, client rpcCaller, q ethereum.FilterQuery, from, to uint64) (types.Logs, error) {
+	query := q
+	query.FromBlock = new(big.Int).SetUint64(from)
+	query.ToBlock = new(big.Int).SetUint64(to)
+	arg, err := toFilterArg(query)
+	if err != nil {
+		return nil, err
+	}
+	var logs types.Logs
+	err = client.Call(ctx, &logs, "eth_getLogs", arg)
+	if err == nil {
+		return logs, nil
+	}
+	if !isLogRangeError(err) || from >= to {
+		return nil, err
+	}
+	return splitFilterLogs(ctx, client, q, from, to)
+}
+
+func splitFilterLogs(ctx context.Context, client rpcCaller, q ethereum.FilterQuery, from, to uint64) (types.Logs, error) {
+	mid := from + (to-from)/2
+	log.Debug("Splitting eth_getLogs block range", "from", from, "to", to, "mid", mid)
+	left, err := filterLogsInRange(ctx, client, q, from, mid)
+	if err != nil {
+		return nil, err
+	}
+	right, err := filterLogsInRange(ctx, client, q, mid+1, to)
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// rangeLimitedCaller emulates a provider that rejects eth_getLogs queries spanning more
+// than maxRange blocks and returns one log per block otherwise.
+type rangeLimitedCaller struct {
+	head     uint64
+	maxRange uint64
+	calls    int
+}
+
+func (c *rangeLimitedCaller) Call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	c.calls++
+	switch method {
+	case "eth_blockNumber":
+		*result.(*hexutil.Uint64) = hexutil.Uint64(c.head)
+		return nil
+	case "eth_getLogs":
+		arg := args[0].(map[string]interface{})
+		from, _ := hexutil.DecodeUint64(arg["fromBlock"].(string))
+		to := c.head
+		if arg["toBlock"] != "latest" {
+			to, _ = hexutil.DecodeUint64(arg["toBlock"].(string))
+		}
+		if to-from+1 > c.maxRange {
+			return errors.New("query returned more than 10000 results")
+		}
+		logs := result.(*types.Logs)
+		for num := from; num <= to; num++ {
+			*logs = append(*logs, &types.Log{BlockNumber: num})
+		}
+		return nil
+	}
+	return errors.New("method not supported")
+}
+
+func (c *rangeLimitedCaller) BatchCall(ctx context.Context, batch []rpc.BatchElem) error {
+	return errors.New("batch not supported")
+}
+
+func TestFilterLogsSplitRange(t *testing.T) {
+	caller := &rangeLimitedCaller{head: 1000, maxRange: 64}
+	query := ethereum.FilterQuery{FromBlock: big.NewInt(100)}
+	logs, err := filterLogs(context.Background(), caller, query)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 901)
+	for idx, log := range logs {
+		assert.Equal(t, uint64(100+idx), log.BlockNumber)
+	}
+}
+
+func TestFilterLogsSingleBlockTooLarge(t *testing.T) {
+	caller := &rangeLimitedCaller{head: 1000, maxRange: 0}
+	query := ethereum.FilterQuery{FromBlock: big.NewInt(10), ToBlock: big.NewInt(11)}
+	_, err := filterLogs(context.Background(), caller, query)
+	assert.True(t, isLogRangeError(err))
+}
+
+// rangeLimitedService is the rangeLimitedCaller served over RPC, it rejects large ranges
+// with an internal error like some providers do.
+type rangeLimitedService struct {
+	caller    rangeLimitedCaller
+	oversized int
+}
+
+func (s *rangeLimitedService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.caller.head)
+}
+
+func (s *rangeLimitedService) GetLogs(arg map[string]interface{}) (types.Logs, error) {
+	var logs types.Logs
+	if err := s.caller.Call(context.Background(), &logs, "eth_getLogs", arg); err != nil {
+		s.oversized++
+		return nil, &testRPCError{-32603, "block range too large"}
+	}
+	for _, log := range logs {
+		log.Topics, log.Data = []common.Hash{}, []byte{}
+	}
+	return logs, nil
+}
+
+func TestPoolSplitsLogRangeWithoutFailover(t *testing.T) {
+	first := &rangeLimitedService{caller: rangeLimitedCaller{head: 100, maxRange: 64}}
+	second := &rangeLimitedService{caller: rangeLimitedCaller{head: 100, maxRange: 64}}
+	pool := NewRpcConnectionPool([]*ETHClient{
+		newTestClient(t, first, Capabilities{}),
+		newTestClient(t, second, Capabilities{}),
+	})
+	defer pool.Close()
+
+	logs, err := pool.FilterLogs(context.Background(), ethereum.FilterQuery{FromBlock: big.NewInt(1), ToBlock: big.NewInt(100)})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 100)
+	assert.Equal(t, 1, first.oversized+second.oversized)
+	assert.Equal(t, clientStatusActive, pool.status[0])
+	assert.Equal(t, clientStatusActive, pool.status[1])
+}
+
+func TestFilterLogsBlockTagError(t *testing.T) {
+	caller := &rangeLimitedCaller{head: 1000, maxRange: 64}
+	_, err := filterLogs(context.Background(), caller, ethereum.FilterQuery{FromBlock: big.NewInt(-2)})
+	assert.EqualError(t, err, "cannot split log range from latest block")
+}
@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	logPollInterval  = 4 * time.Second
+	logTrackerWindow = 128 // maximum reorg depth that is handled precisely
+)
+
+// logTrackerBackend is the subset of RemoteChainReader needed to track logs.
+type logTrackerBackend interface {
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) (types.Logs, error)
+}
+
+type trackedBlock struct {
+	number uint64
+	hash   common.Hash
+	logs   types.Logs
+}
+
+// logTracker follows the canonical chain and emits the logs matching the filter query of
+// every new block. When the chain reorganizes, the logs of the orphaned blocks are emitted
+// again with Removed set, newest first, before the logs of the new canonical blocks.
+type logTracker struct {
+	backend logTrackerBackend
+	query   ethereum.FilterQuery
+	window  []trackedBlock // recently processed canonical blocks, oldest first
+}
+
+func newLogTracker(backend logTrackerBackend, q ethereum.FilterQuery) *logTracker {
+	q.BlockHash, q.FromBlock, q.ToBlock = nil, nil, nil
+	return &logTracker{backend: backend, query: q}
+}
+
+func (t *logTracker) indexOf(hash common.Hash) int {
+	for idx := len(t.window) - 1; idx >= 0; idx-- {
+		if t.window[idx].hash == hash {
+			return idx
+		}
+	}
+	return -1
+}
+
+// start sets the block from which the tracker follows the chain, logs of the block
+// itself are not emitted.
+func (t *logTracker) start(head *types.Header) {
+	t.window = []trackedBlock{{number: head.Number.Uint64(), hash: head.Hash}}
+}
+
+// process moves the tracker to the given chain head, emit returns false to abort.
+func (t *logTracker) process(ctx context.Context, head *types.Header, emit func(types.Log) bool) error {
+	if len(t.window) == 0 {
+		t.start(head)
+		return nil
+	}
+	// a processed block that is not above the tip is a stale head, like the latest block
+	// of a lagging client, it does not revert the blocks after it
+	tip := t.window[len(t.window)-1]
+	if head.Number.Uint64() <= tip.number && t.indexOf(head.Hash) >= 0 {
+		return nil
+	}
+	// walk back from the new head until reaching a block that is already processed
+	var (
+		newBlocks []*types.Header
+		ancestor  = -1
+		oldest    = t.window[0].number
+	)
+	for header := head; ; {
+		if ancestor = t.indexOf(header.Hash); ancestor >= 0 {
+			break
+		}
+		newBlocks = append(newBlocks, header)
+		if header.Number.Uint64() <= oldest {
+			log.Warn("Chain reorganization deeper than tracked window", "number", header.Number, "window", len(t.window))
+			break
+		}
+		parent, err := t.backend.HeaderByHash(ctx, header.ParentHash)
+		if err != nil {
+			return err
+		}
+		header = parent
+	}
+	// revert the orphaned blocks
+	for idx := len(t.window) - 1; idx > ancestor; idx-- {
+		logs := t.window[idx].logs
+		for i := len(logs) - 1; i >= 0; i-- {
+			removed := *logs[i]
+			removed.Removed = true
+			if !emit(removed) {
+				return errSubscriptionClosed
+			}
+		}
+	}
+	t.window = t.window[:ancestor+1]
+	// apply the new canonical blocks
+	for idx := len(newBlocks) - 1; idx >= 0; idx-- {
+		header := newBlocks[idx]
+		query := t.query
+		query.BlockHash = &header.Hash
+		logs, err := t.backend.FilterLogs(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, l := range logs {
+			if !emit(*l) {
+				return errSubscriptionClosed
+			}
+		}
+		t.window = append(t.window, trackedBlock{number: header.Number.Uint64(), hash: header.Hash, logs: logs})
+	}
+	if len(t.window) > logTrackerWindow {
+		t.window = t.window[len(t.window)-logTrackerWindow:]
+	}
+	return nil
+}
+
+var errSubscriptionClosed = errors.New("subscription closed")
+
+type headSubscriber func(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+
+// subscribeFilterLogs tracks the logs matching the filter query. New heads are received
+// through subscribeHeads if given and supported, otherwise the latest block is polled.
+func subscribeFilterLogs(ctx context.Context, reader RemoteChainReader, subscribeHeads headSubscriber, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	var (
+		headCh  = make(chan *types.Header)
+		headSub ethereum.Subscription
+		ticker  *time.Ticker
+		tracker = newLogTracker(reader, q)
+	)
+	if subscribeHeads != nil {
+		sub, err := subscribeHeads(ctx, headCh)
+		if err != nil && err != rpc.ErrNotificationsUnsupported {
+			return nil, err
+		}
+		headSub = sub
+	}
+	head, err := reader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		if headSub != nil {
+			headSub.Unsubscribe()
+		}
+		return nil, err
+	}
+	tracker.start(head)
+	if headSub == nil {
+		ticker = time.NewTicker(logPollInterval)
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-quit
+			cancel()
+		}()
+		emit := func(l types.Log) bool {
+			select {
+			case ch <- l:
+				return true
+			case <-quit:
+				return false
+			}
+		}
+		var subErr <-chan error
+		if headSub != nil {
+			defer headSub.Unsubscribe()
+			subErr = headSub.Err()
+		} else {
+			defer ticker.Stop()
+		}
+		for {
+			var head *types.Header
+			select {
+			case head = <-headCh:
+			case <-tickerChan(ticker):
+				latest, err := reader.HeaderByNumber(ctx, nil)
+				if err != nil {
+					log.Debug("Failed to poll latest block", "error", err)
+					continue
+				}
+				head = latest
+			case err := <-subErr:
+				return err
+			case <-quit:
+				return nil
+			}
+			if err := tracker.process(ctx, head, emit); err != nil {
+				if err == errSubscriptionClosed {
+					return nil
+				}
+				log.Warn("Failed to process logs of new head", "number", head.Number, "hash", head.Hash, "error", err)
+			}
+		}
+	}), nil
+}
+
+func tickerChan(ticker *time.Ticker) <-chan time.Time {
+	if ticker == nil {
+		return nil
+	}
+	return ticker.C
+}
+
+// SubscribeFilterLogs subscribes to the logs matching the filter query. On websocket and IPC
+// endpoints new blocks are received through newHeads notifications, HTTP endpoints are
+// polled. Logs of blocks orphaned by a reorg are re-emitted with Removed set.
+func (ec *ETHClient) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	var subscribeHeads headSubscriber
+	if supportsSubscriptions(ec.url) {
+		subscribeHeads = ec.SubscribeNewHead
+	}
+	return subscribeFilterLogs(ctx, ec, subscribeHeads, q, ch)
+}
+
+// SubscribeFilterLogs subscribes to the logs matching the filter query, see
+// ETHClient.SubscribeFilterLogs.
+func (p *RpcConnectionPool) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return subscribeFilterLogs(ctx, p, p.SubscribeNewHead, q, ch)
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChain struct {
+	headers map[common.Hash]*types.Header
+}
+
+func (c *fakeChain) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	header, ok := c.headers[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return header, nil
+}
+
+func (c *fakeChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) (types.Logs, error) {
+	header := c.headers[*q.BlockHash]
+	return types.Logs{{BlockNumber: header.Number.Uint64(), BlockHash: header.Hash}}, nil
+}
+
+// extend appends count blocks on top of parent, fork distinguishes blocks of different
+// branches at the same height.
+func (c *fakeChain) extend(parent *types.Header, count int, fork byte) []*types.Header {
+	headers := make([]*types.Header, count)
+	for idx := range headers {
+		number := new(big.Int).Add(parent.Number, big1)
+		header := &types.Header{
+			Number:     number,
+			ParentHash: parent.Hash,
+			Hash:       common.BytesToHash([]byte{fork, byte(number.Uint64())}),
+		}
+		c.headers[header.Hash] = header
+		headers[idx], parent = header, header
+	}
+	return headers
+}
+
+func TestLogTrackerReorg(t *testing.T) {
+	chain := &fakeChain{headers: make(map[common.Hash]*types.Header)}
+	genesis := &types.Header{Number: big.NewInt(0), Hash: common.HexToHash("0x01")}
+	chain.headers[genesis.Hash] = genesis
+	canonical := chain.extend(genesis, 3, 0xaa)
+	fork := chain.extend(canonical[0], 3, 0xbb)
+
+	var emitted []types.Log
+	emit := func(l types.Log) bool {
+		emitted = append(emitted, l)
+		return true
+	}
+	tracker := newLogTracker(chain, ethereum.FilterQuery{})
+	tracker.start(genesis)
+	assert.NoError(t, tracker.process(context.Background(), canonical[2], emit))
+	assert.Len(t, emitted, 3)
+
+	emitted = nil
+	assert.NoError(t, tracker.process(context.Background(), fork[2], emit))
+	expected := []struct {
+		hash    common.Hash
+		removed bool
+	}{
+		{canonical[2].Hash, true},
+		{canonical[1].Hash, true},
+		{fork[0].Hash, false},
+		{fork[1].Hash, false},
+		{fork[2].Hash, false},
+	}
+	assert.Len(t, emitted, len(expected))
+	for idx, log := range emitted {
+		assert.Equal(t, expected[idx].hash, log.BlockHash)
+		assert.Equal(t, expected[idx].removed, log.Removed)
+	}
+}
+
+func TestLogTrackerIgnoresStaleHead(t *testing.T) {
+	chain := &fakeChain{headers: make(map[common.Hash]*types.Header)}
+	genesis := &types.Header{Number: big.NewInt(0), Hash: common.HexToHash("0x01")}
+	chain.headers[genesis.Hash] = genesis
+	canonical := chain.extend(genesis, 4, 0xaa)
+
+	var emitted []types.Log
+	emit := func(l types.Log) bool {
+		emitted = append(emitted, l)
+		return true
+	}
+	tracker := newLogTracker(chain, ethereum.FilterQuery{})
+	tracker.start(genesis)
+	assert.NoError(t, tracker.process(context.Background(), canonical[2], emit))
+	assert.Len(t, emitted, 3)
+
+	// the older heads of a lagging client are neither reverted nor emitted again
+	emitted = nil
+	assert.NoError(t, tracker.process(context.Background(), canonical[1], emit))
+	assert.NoError(t, tracker.process(context.Background(), canonical[2], emit))
+	assert.NoError(t, tracker.process(context.Background(), canonical[0], emit))
+	assert.Empty(t, emitted)
+
+	assert.NoError(t, tracker.process(context.Background(), canonical[3], emit))
+	assert.Len(t, emitted, 1)
+	assert.Equal(t, canonical[3].Hash, emitted[0].BlockHash)
+	assert.False(t, emitted[0].Removed)
+}
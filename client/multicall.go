@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	multicall3ABI = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+	defaultMulticallMaxCalldataSize = 64 * 1024
+	defaultMulticallMaxGas          = 50_000_000
+	multicallCallOverhead           = 128 // abi encoding overhead of a single call, in bytes
+)
+
+// Multicall3Address is the canonical address of the Multicall3 contract, it is deployed at
+// the same address on most EVM chains.
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+var parsedMulticall3ABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// MulticallConfig holds the options of Multicall, zero values are replaced by defaults.
+// MaxGas only applies to calls with Gas set, the gas of other calls is not known upfront.
+type MulticallConfig struct {
+	Address         common.Address // address of the Multicall3 contract
+	MaxCalldataSize int            // maximum calldata size of a single aggregate3 call
+	MaxGas          uint64         // maximum sum of the gas limits of the calls in one aggregate3 call
+}
+
+// MulticallResult is the result of a single aggregated call.
+type MulticallResult struct {
+	Success    bool
+	ReturnData []byte
+	Err        error // error of the call when it was sent through JSON-RPC batching
+}
+
+// Multicall bundles many contract calls into aggregate3 calls against the Multicall3
+// contract. If the contract is not deployed at the requested block, the calls are sent
+// through JSON-RPC batching instead.
+type Multicall struct {
+	reader          RemoteChainReader
+	address         common.Address
+	maxCalldataSize int
+	maxGas          uint64
+
+	mu             sync.Mutex
+	deployedAt     *big.Int // lowest block known to have the contract deployed
+	deployedLatest bool
+}
+
+func NewMulticall(reader RemoteChainReader, config *MulticallConfig) *Multicall {
+	m := &Multicall{
+		reader:          reader,
+		address:         Multicall3Address,
+		maxCalldataSize: defaultMulticallMaxCalldataSize,
+		maxGas:          defaultMulticallMaxGas,
+	}
+	if config != nil {
+		if config.Address != (common.Address{}) {
+			m.address = config.Address
+		}
+		if config.MaxCalldataSize > 0 {
+			m.maxCalldataSize = config.MaxCalldataSize
+		}
+		if config.MaxGas > 0 {
+			m.maxGas = config.MaxGas
+		}
+	}
+	return m
+}
+
+// isDeployed reports whether the Multicall3 contract exists at the given block. Once
+// deployed, the contract exists in all later blocks.
+func (m *Multicall) isDeployed(ctx context.Context, number *big.Int) (bool, error) {
+	m.mu.Lock()
+	if number == nil && m.deployedLatest || number != nil && m.deployedAt != nil && number.Cmp(m.deployedAt) >= 0 {
+		m.mu.Unlock()
+		return true, nil
+	}
+	m.mu.Unlock()
+	code, err := m.reader.CodeAt(ctx, m.address, number)
+	if err != nil || len(code) == 0 {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if number == nil {
+		m.deployedLatest = true
+	} else if number.Sign() >= 0 && (m.deployedAt == nil || number.Cmp(m.deployedAt) < 0) {
+		m.deployedAt = new(big.Int).Set(number)
+	}
+	return true, nil
+}
+
+// chunk splits the calls into ranges that respect the calldata size and gas limits.
+func (m *Multicall) chunk(msgs []ethereum.CallMsg) [][2]int {
+	var (
+		chunks      [][2]int
+		start, size int
+		gas         uint64
+	)
+	for idx, msg := range msgs {
+		callSize := len(msg.Data) + multicallCallOverhead
+		if idx > start && (size+callSize > m.maxCalldataSize || gas+msg.Gas > m.maxGas) {
+			chunks = append(chunks, [2]int{start, idx})
+			start, size, gas = idx, 0, 0
+		}
+		size += callSize
+		gas += msg.Gas
+	}
+	if start < len(msgs) {
+		chunks = append(chunks, [2]int{start, len(msgs)})
+	}
+	return chunks
+}
+
+func (m *Multicall) aggregate3(ctx context.Context, msgs []ethereum.CallMsg, number *big.Int) ([]MulticallResult, error) {
+	calls := make([]multicall3Call, len(msgs))
+	for idx, msg := range msgs {
+		calls[idx] = multicall3Call{Target: *msg.To, AllowFailure: true, CallData: msg.Data}
+	}
+	input, err := parsedMulticall3ABI.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, err
+	}
+	output, err := m.reader.CallContract(ctx, ethereum.CallMsg{To: &m.address, Data: input}, number)
+	if err != nil {
+		return nil, err
+	}
+	unpacked, err := parsedMulticall3ABI.Unpack("aggregate3", output)
+	if err != nil {
+		return nil, err
+	}
+	returns := *abi.ConvertType(unpacked[0], new([]multicall3Result)).(*[]multicall3Result)
+	if len(returns) != len(msgs) {
+		return nil, fmt.Errorf("got %d multicall results, expected %d", len(returns), len(msgs))
+	}
+	results := make([]MulticallResult, len(msgs))
+	for idx, ret := range returns {
+		results[idx] = MulticallResult{Success: ret.Success, ReturnData: ret.ReturnData}
+	}
+	return results, nil
+}
+
+func (m *Multicall) batchCall(ctx context.Context, msgs []ethereum.CallMsg, number *big.Int) ([]MulticallResult, error) {
+	returns := make([]hexutil.Bytes, len(msgs))
+	batch := make([]rpc.BatchElem, len(msgs))
+	for idx, msg := range msgs {
+		batch[idx] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []interface{}{toCallArg(msg), toBlockNumArg(number)},
+			Result: &returns[idx],
+		}
+	}
+	for start := 0; start < len(batch); start += rpcRequestBatchSize {
+		end := start + rpcRequestBatchSize
+		if end > len(batch) {
+			end = len(batch)
+		}
+		// failed calls are reported per element, only abort on transport errors
+		if err := m.reader.BatchCall(ctx, batch[start:end]); err != nil && getBatchErr(batch[start:end]) == nil {
+			return nil, err
+		}
+	}
+	results := make([]MulticallResult, len(msgs))
+	for idx, elem := range batch {
+		results[idx] = MulticallResult{Success: elem.Error == nil, ReturnData: returns[idx], Err: elem.Error}
+	}
+	return results, nil
+}
+
+// Aggregate executes the calls at the given block and returns their results in order.
+// Only the target and the calldata of each call are used by aggregate3, the caller seen by
+// the targets is the Multicall3 contract, so calls with a sender or a non-zero value are
+// rejected.
+// The gas limit of a call only counts towards MaxGas, it is not enforced by aggregate3.
+func (m *Multicall) Aggregate(ctx context.Context, msgs []ethereum.CallMsg, blockNumber *big.Int) ([]MulticallResult, error) {
+	for idx, msg := range msgs {
+		switch {
+		case msg.To == nil:
+			return nil, errors.New("multicall does not support contract creation")
+		case msg.From != (common.Address{}):
+			return nil, fmt.Errorf("multicall does not support a sender, call %d has one", idx)
+		case msg.Value != nil && msg.Value.Sign() != 0:
+			return nil, fmt.Errorf("multicall does not support a value, call %d has one", idx)
+		}
+	}
+	deployed, err := m.isDeployed(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if !deployed {
+		return m.batchCall(ctx, msgs, blockNumber)
+	}
+	results := make([]MulticallResult, 0, len(msgs))
+	for _, chunk := range m.chunk(msgs) {
+		ret, err := m.aggregate3(ctx, msgs[chunk[0]:chunk[1]], blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, ret...)
+	}
+	return results, nil
+}
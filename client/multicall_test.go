@@ -0,0 +1,102 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// multicallBackend emulates the Multicall3 contract by echoing the calldata of each call,
+// calls with empty calldata fail.
+type multicallBackend struct {
+	RemoteChainReader
+	calls int
+}
+
+func (b *multicallBackend) CodeAt(ctx context.Context, account common.Address, number *big.Int) ([]byte, error) {
+	return []byte{0x60, 0x80}, nil
+}
+
+func (b *multicallBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	b.calls++
+	method := parsedMulticall3ABI.Methods["aggregate3"]
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	var calls []multicall3Call
+	if err := method.Inputs.Copy(&calls, args); err != nil {
+		return nil, err
+	}
+	results := make([]multicall3Result, len(calls))
+	for idx, call := range calls {
+		results[idx] = multicall3Result{Success: len(call.CallData) > 0, ReturnData: call.CallData}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func TestMulticallAggregate(t *testing.T) {
+	backend := &multicallBackend{}
+	multicall := NewMulticall(backend, &MulticallConfig{MaxCalldataSize: 3 * (multicallCallOverhead + 4)})
+	target := common.HexToAddress("0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d")
+	msgs := make([]ethereum.CallMsg, 7)
+	for idx := range msgs {
+		msgs[idx] = ethereum.CallMsg{To: &target, Data: []byte{0x70, 0xa0, 0x82, byte(idx)}}
+	}
+	msgs[4].Data = nil
+
+	results, err := multicall.Aggregate(context.Background(), msgs, big.NewInt(18000000))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, backend.calls)
+	assert.Len(t, results, len(msgs))
+	for idx, result := range results {
+		assert.Equal(t, idx != 4, result.Success)
+		assert.True(t, bytes.Equal(msgs[idx].Data, result.ReturnData))
+	}
+}
+
+func TestMulticallMaxGas(t *testing.T) {
+	backend := &multicallBackend{}
+	multicall := NewMulticall(backend, &MulticallConfig{MaxGas: 100_000})
+	target := common.HexToAddress("0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d")
+	msgs := make([]ethereum.CallMsg, 5)
+	for idx := range msgs {
+		msgs[idx] = ethereum.CallMsg{To: &target, Data: []byte{byte(idx + 1)}}
+	}
+
+	// calls without a gas limit do not count towards MaxGas
+	_, err := multicall.Aggregate(context.Background(), msgs, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, backend.calls)
+
+	backend.calls = 0
+	for idx := range msgs {
+		msgs[idx].Gas = 40_000
+	}
+	results, err := multicall.Aggregate(context.Background(), msgs, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, backend.calls)
+	assert.Len(t, results, len(msgs))
+}
+
+func TestMulticallRejectsSenderAndValue(t *testing.T) {
+	backend := &multicallBackend{}
+	multicall := NewMulticall(backend, nil)
+	target := common.HexToAddress("0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d")
+
+	_, err := multicall.Aggregate(context.Background(), []ethereum.CallMsg{{To: &target, Value: big.NewInt(1)}}, nil)
+	assert.Error(t, err)
+	_, err = multicall.Aggregate(context.Background(), []ethereum.CallMsg{{To: &target, From: common.HexToAddress("0x01")}}, nil)
+	assert.Error(t, err)
+	assert.Zero(t, backend.calls)
+
+	// a zero value is the same as no value
+	_, err = multicall.Aggregate(context.Background(), []ethereum.CallMsg{{To: &target, Data: []byte{0x01}, Value: big.NewInt(0)}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, backend.calls)
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// OverrideAccount specifies the state of an account to be overridden during a call.
+// State replaces the whole storage of the account, StateDiff only replaces the given slots,
+// they are mutually exclusive.
+type OverrideAccount struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[common.Hash]common.Hash
+	StateDiff map[common.Hash]common.Hash
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a OverrideAccount) MarshalJSON() ([]byte, error) {
+	type acc struct {
+		Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+		Code      *hexutil.Bytes              `json:"code,omitempty"`
+		Balance   *hexutil.Big                `json:"balance,omitempty"`
+		State     map[common.Hash]common.Hash `json:"state,omitempty"`
+		StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+	}
+	output := acc{
+		Nonce:     (*hexutil.Uint64)(a.Nonce),
+		Balance:   (*hexutil.Big)(a.Balance),
+		State:     a.State,
+		StateDiff: a.StateDiff,
+	}
+	if a.Code != nil {
+		output.Code = (*hexutil.Bytes)(&a.Code)
+	}
+	return json.Marshal(output)
+}
+
+// StateOverride is the collection of overridden accounts.
+type StateOverride map[common.Address]OverrideAccount
+
+// BlockOverrides specifies the fields of the block context to be overridden during a call.
+type BlockOverrides struct {
+	Number   *big.Int
+	Time     *uint64
+	GasLimit *uint64
+	Coinbase *common.Address
+	BaseFee  *big.Int
+}
+
+// MarshalJSON implements json.Marshaler. The fields are encoded with the names of the
+// execution API spec used by eth_simulateV1.
+func (o BlockOverrides) MarshalJSON() ([]byte, error) {
+	type overrides struct {
+		Number        *hexutil.Big    `json:"number,omitempty"`
+		Time          *hexutil.Uint64 `json:"time,omitempty"`
+		GasLimit      *hexutil.Uint64 `json:"gasLimit,omitempty"`
+		FeeRecipient  *common.Address `json:"feeRecipient,omitempty"`
+		BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas,omitempty"`
+	}
+	return json.Marshal(overrides{
+		Number:        (*hexutil.Big)(o.Number),
+		Time:          (*hexutil.Uint64)(o.Time),
+		GasLimit:      (*hexutil.Uint64)(o.GasLimit),
+		FeeRecipient:  o.Coinbase,
+		BaseFeePerGas: (*hexutil.Big)(o.BaseFee),
+	})
+}
+
+// callBlockOverrides encodes the block overrides of eth_call. The coinbase and base fee
+// are encoded with both the names of the execution API spec and the legacy names still
+// read by geth before 1.14 and Erigon, unknown fields are ignored by the servers.
+type callBlockOverrides BlockOverrides
+
+func (o callBlockOverrides) MarshalJSON() ([]byte, error) {
+	type overrides struct {
+		Number        *hexutil.Big    `json:"number,omitempty"`
+		Time          *hexutil.Uint64 `json:"time,omitempty"`
+		GasLimit      *hexutil.Uint64 `json:"gasLimit,omitempty"`
+		Coinbase      *common.Address `json:"coinbase,omitempty"`
+		FeeRecipient  *common.Address `json:"feeRecipient,omitempty"`
+		BaseFee       *hexutil.Big    `json:"baseFee,omitempty"`
+		BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas,omitempty"`
+	}
+	return json.Marshal(overrides{
+		Number:        (*hexutil.Big)(o.Number),
+		Time:          (*hexutil.Uint64)(o.Time),
+		GasLimit:      (*hexutil.Uint64)(o.GasLimit),
+		Coinbase:      o.Coinbase,
+		FeeRecipient:  o.Coinbase,
+		BaseFee:       (*hexutil.Big)(o.BaseFee),
+		BaseFeePerGas: (*hexutil.Big)(o.BaseFee),
+	})
+}
+
+func callContractWithOverrides(ctx context.Context, client rpcCaller, msg ethereum.CallMsg, number *big.Int, overrides StateOverride, blockOverrides *BlockOverrides) ([]byte, error) {
+	args := []interface{}{toCallArg(msg), toBlockNumArg(number), overrides}
+	if blockOverrides != nil {
+		args = append(args, (*callBlockOverrides)(blockOverrides))
+	}
+	var hex hexutil.Bytes
+	if err := client.Call(ctx, &hex, "eth_call", args...); err != nil {
+		return nil, toRevertError(err)
+	}
+	return hex, nil
+}
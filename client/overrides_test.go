@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// overrideService records the overrides of eth_call.
+type overrideService struct {
+	overrides      map[string]interface{}
+	blockOverrides map[string]interface{}
+}
+
+func (s *overrideService) Call(args map[string]interface{}, number string, overrides, blockOverrides map[string]interface{}) hexutil.Bytes {
+	s.overrides, s.blockOverrides = overrides, blockOverrides
+	return hexutil.Bytes{0x01}
+}
+
+func TestCallContractWithOverrides(t *testing.T) {
+	service := &overrideService{}
+	ec := newTestClient(t, service, Capabilities{})
+	defer ec.Close()
+
+	var (
+		account  = common.HexToAddress("0x01")
+		coinbase = common.HexToAddress("0x02")
+		nonce    = uint64(5)
+		time     = uint64(1700000000)
+	)
+	overrides := StateOverride{account: {
+		Nonce:     &nonce,
+		Code:      []byte{0x60, 0x00},
+		Balance:   big.NewInt(100),
+		StateDiff: map[common.Hash]common.Hash{common.HexToHash("0x01"): common.HexToHash("0x02")},
+	}}
+	blockOverrides := &BlockOverrides{
+		Number:   big.NewInt(16),
+		Time:     &time,
+		Coinbase: &coinbase,
+		BaseFee:  big.NewInt(7),
+	}
+	result, err := ec.CallContractWithOverrides(context.Background(), ethereum.CallMsg{To: &account}, nil, overrides, blockOverrides)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01}, result)
+
+	assert.Equal(t, map[string]interface{}{
+		account.Hex(): map[string]interface{}{
+			"nonce":     "0x5",
+			"code":      "0x6000",
+			"balance":   "0x64",
+			"stateDiff": map[string]interface{}{common.HexToHash("0x01").Hex(): common.HexToHash("0x02").Hex()},
+		},
+	}, service.overrides)
+	assert.Equal(t, map[string]interface{}{
+		"number":        "0x10",
+		"time":          "0x6553f100",
+		"coinbase":      coinbase.Hex(),
+		"feeRecipient":  coinbase.Hex(),
+		"baseFee":       "0x7",
+		"baseFeePerGas": "0x7",
+	}, service.blockOverrides)
+
+	// eth_simulateV1 only takes the names of the execution API spec
+	enc, err := json.Marshal(blockOverrides)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"number":"0x10","time":"0x6553f100","feeRecipient":"`+coinbase.Hex()+`","baseFeePerGas":"0x7"}`, string(enc))
+}
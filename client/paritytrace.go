@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TraceFilterQuery contains the options of trace_filter.
+type TraceFilterQuery struct {
+	FromBlock   *big.Int         // beginning of the queried range, nil means genesis block
+	ToBlock     *big.Int         // end of the range, nil means latest block
+	FromAddress []common.Address // restricts traces to actions sent from these addresses
+	ToAddress   []common.Address // restricts traces to actions sent to these addresses
+	After       uint64           // number of traces to skip
+	Count       uint64           // maximum number of traces to return, zero means unlimited
+}
+
+// TraceReplayOptions selects the optional traces of trace_replayTransaction and
+// trace_replayBlockTransactions, the flat call traces are always included.
+type TraceReplayOptions struct {
+	StateDiff bool
+	VmTrace   bool
+}
+
+func (opts *TraceReplayOptions) traceTypes() []string {
+	traceTypes := []string{"trace"}
+	if opts != nil && opts.StateDiff {
+		traceTypes = append(traceTypes, "stateDiff")
+	}
+	if opts != nil && opts.VmTrace {
+		traceTypes = append(traceTypes, "vmTrace")
+	}
+	return traceTypes
+}
+
+func toTraceFilterArg(q TraceFilterQuery) interface{} {
+	arg := map[string]interface{}{
+		"toBlock": toBlockNumArg(q.ToBlock),
+	}
+	if q.FromBlock == nil {
+		arg["fromBlock"] = "0x0"
+	} else {
+		arg["fromBlock"] = toBlockNumArg(q.FromBlock)
+	}
+	if len(q.FromAddress) > 0 {
+		arg["fromAddress"] = q.FromAddress
+	}
+	if len(q.ToAddress) > 0 {
+		arg["toAddress"] = q.ToAddress
+	}
+	if q.After > 0 {
+		arg["after"] = q.After
+	}
+	if q.Count > 0 {
+		arg["count"] = q.Count
+	}
+	return arg
+}
+
+func traceBlock(ctx context.Context, client rpcCaller, number *big.Int) ([]*types.FlatTrace, error) {
+	var result []*types.FlatTrace
+	err := client.Call(ctx, &result, "trace_block", toBlockNumArg(number))
+	return result, err
+}
+
+func traceTransaction(ctx context.Context, client rpcCaller, txHash common.Hash) ([]*types.FlatTrace, error) {
+	var result []*types.FlatTrace
+	err := client.Call(ctx, &result, "trace_transaction", txHash)
+	return result, err
+}
+
+func traceFilter(ctx context.Context, client rpcCaller, q TraceFilterQuery) ([]*types.FlatTrace, error) {
+	var result []*types.FlatTrace
+	err := client.Call(ctx, &result, "trace_filter", toTraceFilterArg(q))
+	return result, err
+}
+
+func traceReplayTransaction(ctx context.Context, client rpcCaller, txHash common.Hash, opts *TraceReplayOptions) (*types.TraceReplayResult, error) {
+	var result *types.TraceReplayResult
+	err := client.Call(ctx, &result, "trace_replayTransaction", txHash, opts.traceTypes())
+	return result, err
+}
+
+func traceReplayBlockTransactions(ctx context.Context, client rpcCaller, number *big.Int, opts *TraceReplayOptions) ([]*types.TraceReplayResult, error) {
+	var result []*types.TraceReplayResult
+	err := client.Call(ctx, &result, "trace_replayBlockTransactions", toBlockNumArg(number), opts.traceTypes())
+	return result, err
+}
+
+func (ec *ETHClient) TraceBlock(ctx context.Context, number *big.Int) ([]*types.FlatTrace, error) {
+	return traceBlock(ctx, ec, number)
+}
+
+func (ec *ETHClient) TraceTransaction(ctx context.Context, txHash common.Hash) ([]*types.FlatTrace, error) {
+	return traceTransaction(ctx, ec, txHash)
+}
+
+func (ec *ETHClient) TraceFilter(ctx context.Context, q TraceFilterQuery) ([]*types.FlatTrace, error) {
+	return traceFilter(ctx, ec, q)
+}
+
+func (ec *ETHClient) TraceReplayTransaction(ctx context.Context, txHash common.Hash, opts *TraceReplayOptions) (*types.TraceReplayResult, error) {
+	return traceReplayTransaction(ctx, ec, txHash, opts)
+}
+
+func (ec *ETHClient) TraceReplayBlockTransactions(ctx context.Context, number *big.Int, opts *TraceReplayOptions) ([]*types.TraceReplayResult, error) {
+	return traceReplayBlockTransactions(ctx, ec, number, opts)
+}
+
+func (p *RpcConnectionPool) TraceBlock(ctx context.Context, number *big.Int) ([]*types.FlatTrace, error) {
+	return traceBlock(ctx, p, number)
+}
+
+func (p *RpcConnectionPool) TraceTransaction(ctx context.Context, txHash common.Hash) ([]*types.FlatTrace, error) {
+	return traceTransaction(ctx, p, txHash)
+}
+
+func (p *RpcConnectionPool) TraceFilter(ctx context.Context, q TraceFilterQuery) ([]*types.FlatTrace, error) {
+	return traceFilter(ctx, p, q)
+}
+
+func (p *RpcConnectionPool) TraceReplayTransaction(ctx context.Context, txHash common.Hash, opts *TraceReplayOptions) (*types.TraceReplayResult, error) {
+	return traceReplayTransaction(ctx, p, txHash, opts)
+}
+
+func (p *RpcConnectionPool) TraceReplayBlockTransactions(ctx context.Context, number *big.Int, opts *TraceReplayOptions) ([]*types.TraceReplayResult, error) {
+	return traceReplayBlockTransactions(ctx, p, number, opts)
+}
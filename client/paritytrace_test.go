@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// parityTraceService serves the trace namespace and records the arguments of the last
+// request.
+type parityTraceService struct {
+	filter     json.RawMessage
+	block      string
+	traceTypes []string
+}
+
+func (s *parityTraceService) Filter(filter json.RawMessage) []interface{} {
+	s.filter = filter
+	return []interface{}{}
+}
+
+func (s *parityTraceService) ReplayTransaction(hash common.Hash, traceTypes []string) map[string]interface{} {
+	s.traceTypes = traceTypes
+	return map[string]interface{}{"output": "0x01", "stateDiff": nil, "trace": []interface{}{}, "vmTrace": nil}
+}
+
+func (s *parityTraceService) ReplayBlockTransactions(number string, traceTypes []string) []map[string]interface{} {
+	s.block, s.traceTypes = number, traceTypes
+	return []map[string]interface{}{{"output": "0x", "stateDiff": nil, "trace": []interface{}{}, "vmTrace": nil}}
+}
+
+func TestTraceFilterArg(t *testing.T) {
+	service := &parityTraceService{}
+	ec := newNamespaceTestClient(t, "trace", service)
+	defer ec.Close()
+
+	from := common.HexToAddress("0x01")
+	tests := []struct {
+		query TraceFilterQuery
+		want  string
+	}{
+		{TraceFilterQuery{}, `{"fromBlock":"0x0","toBlock":"latest"}`},
+		{
+			TraceFilterQuery{FromBlock: big.NewInt(16), ToBlock: big.NewInt(32), FromAddress: []common.Address{from}, After: 10, Count: 100},
+			`{"fromBlock":"0x10","toBlock":"0x20","fromAddress":["` + from.Hex() + `"],"after":10,"count":100}`,
+		},
+	}
+	for _, tt := range tests {
+		_, err := ec.TraceFilter(context.Background(), tt.query)
+		assert.NoError(t, err)
+		assert.JSONEq(t, tt.want, string(service.filter))
+	}
+}
+
+func TestTraceReplayOptions(t *testing.T) {
+	service := &parityTraceService{}
+	ec := newNamespaceTestClient(t, "trace", service)
+	defer ec.Close()
+
+	tests := []struct {
+		opts *TraceReplayOptions
+		want []string
+	}{
+		{nil, []string{"trace"}},
+		{&TraceReplayOptions{StateDiff: true}, []string{"trace", "stateDiff"}},
+		{&TraceReplayOptions{StateDiff: true, VmTrace: true}, []string{"trace", "stateDiff", "vmTrace"}},
+	}
+	for _, tt := range tests {
+		result, err := ec.TraceReplayTransaction(context.Background(), common.Hash{}, tt.opts)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0x01}, result.Output)
+		assert.Equal(t, tt.want, service.traceTypes)
+	}
+
+	results, err := ec.TraceReplayBlockTransactions(context.Background(), big.NewInt(16), &TraceReplayOptions{VmTrace: true})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "0x10", service.block)
+	assert.Equal(t, []string{"trace", "vmTrace"}, service.traceTypes)
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// AccountProof is the result of an eth_getProof call, it contains the account state and
+// the Merkle-Patricia proofs of the account and the requested storage slots.
+type AccountProof struct {
+	Address      common.Address
+	AccountProof []hexutil.Bytes
+	Balance      *big.Int
+	CodeHash     common.Hash
+	Nonce        uint64
+	StorageHash  common.Hash
+	StorageProof []StorageProof
+}
+
+// StorageProof is the Merkle-Patricia proof of a single storage slot.
+type StorageProof struct {
+	Key   common.Hash
+	Value *big.Int
+	Proof []hexutil.Bytes
+}
+
+type accountProofJSON struct {
+	Address      common.Address     `json:"address"`
+	AccountProof []hexutil.Bytes    `json:"accountProof"`
+	Balance      *hexutil.Big       `json:"balance"`
+	CodeHash     common.Hash        `json:"codeHash"`
+	Nonce        hexutil.Uint64     `json:"nonce"`
+	StorageHash  common.Hash        `json:"storageHash"`
+	StorageProof []storageProofJSON `json:"storageProof"`
+}
+
+type storageProofJSON struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// rlpAccount is the consensus encoding of an account in the state trie.
+type rlpAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+func newProofDB(proof []hexutil.Bytes) *memorydb.Database {
+	db := memorydb.New()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// Verify checks the account proof and all storage proofs against the given state root.
+func (p *AccountProof) Verify(stateRoot common.Hash) error {
+	value, err := trie.VerifyProof(stateRoot, crypto.Keccak256(p.Address[:]), newProofDB(p.AccountProof))
+	if err != nil {
+		return fmt.Errorf("invalid account proof for %s: %v", p.Address, err)
+	}
+	account := rlpAccount{
+		Balance:  new(big.Int),
+		Root:     types.EmptyRootHash,
+		CodeHash: types.EmptyCodeHash[:],
+	}
+	if value != nil {
+		if err := rlp.DecodeBytes(value, &account); err != nil {
+			return fmt.Errorf("invalid account data for %s: %v", p.Address, err)
+		}
+	}
+	balance := p.Balance
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	if account.Nonce != p.Nonce || account.Balance.Cmp(balance) != 0 {
+		return fmt.Errorf("account %s state does not match proof", p.Address)
+	}
+	// non-existent accounts may be reported with zero hashes by some clients
+	if value != nil || (p.CodeHash != common.Hash{}) {
+		if !bytes.Equal(account.CodeHash, p.CodeHash[:]) {
+			return fmt.Errorf("account %s code hash does not match proof", p.Address)
+		}
+	}
+	if value != nil || (p.StorageHash != common.Hash{}) {
+		if account.Root != p.StorageHash {
+			return fmt.Errorf("account %s storage hash does not match proof", p.Address)
+		}
+	}
+	for _, storage := range p.StorageProof {
+		if err := storage.Verify(account.Root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks the storage proof against the given storage root.
+func (sp *StorageProof) Verify(storageRoot common.Hash) error {
+	var value []byte
+	// the empty trie has no node to prove the absence of the key with
+	if storageRoot != types.EmptyRootHash {
+		var err error
+		value, err = trie.VerifyProof(storageRoot, crypto.Keccak256(sp.Key[:]), newProofDB(sp.Proof))
+		if err != nil {
+			return fmt.Errorf("invalid storage proof for key %s: %v", sp.Key, err)
+		}
+	}
+	slot := new(big.Int)
+	if value != nil {
+		var content []byte
+		if err := rlp.DecodeBytes(value, &content); err != nil {
+			return fmt.Errorf("invalid storage data for key %s: %v", sp.Key, err)
+		}
+		slot.SetBytes(content)
+	}
+	expected := sp.Value
+	if expected == nil {
+		expected = new(big.Int)
+	}
+	if slot.Cmp(expected) != 0 {
+		return fmt.Errorf("storage value of key %s does not match proof", sp.Key)
+	}
+	return nil
+}
+
+func getProof(ctx context.Context, client rpcCaller, account common.Address, keys []common.Hash, number *big.Int) (*AccountProof, error) {
+	if keys == nil {
+		keys = []common.Hash{}
+	}
+	var res accountProofJSON
+	if err := client.Call(ctx, &res, "eth_getProof", account, keys, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	proof := &AccountProof{
+		Address:      res.Address,
+		AccountProof: res.AccountProof,
+		Balance:      (*big.Int)(res.Balance),
+		CodeHash:     res.CodeHash,
+		Nonce:        uint64(res.Nonce),
+		StorageHash:  res.StorageHash,
+		StorageProof: make([]StorageProof, len(res.StorageProof)),
+	}
+	for idx, sp := range res.StorageProof {
+		proof.StorageProof[idx] = StorageProof{
+			Key:   common.HexToHash(sp.Key),
+			Value: (*big.Int)(sp.Value),
+			Proof: sp.Proof,
+		}
+	}
+	return proof, nil
+}
+
+// getVerifiedProof retrieves the header of the requested block and the account proof at
+// that block, then verifies the proof against the state root of the header.
+func getVerifiedProof(ctx context.Context, client RemoteChainReader, account common.Address, keys []common.Hash, number *big.Int) (*AccountProof, error) {
+	header, err := client.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := client.ProofAt(ctx, account, keys, header.Number)
+	if err != nil {
+		return nil, err
+	}
+	if proof.Address != account {
+		return nil, fmt.Errorf("got proof of wrong account %s, expected %s", proof.Address, account)
+	}
+	if len(proof.StorageProof) != len(keys) {
+		return nil, fmt.Errorf("got %d storage proofs, expected %d", len(proof.StorageProof), len(keys))
+	}
+	for idx, key := range keys {
+		if proof.StorageProof[idx].Key != key {
+			return nil, fmt.Errorf("got proof of wrong storage key %s, expected %s", proof.StorageProof[idx].Key, key)
+		}
+	}
+	if err := proof.Verify(header.Root); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
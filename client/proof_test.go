@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	proofAccount = common.HexToAddress("0x7f0d15c7faae65896648c8273b6d7e43f58fa842")
+	proofAbsent  = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+	proofKeys    = []common.Hash{common.BigToHash(big.NewInt(3)), common.BigToHash(big.NewInt(99))}
+)
+
+// proofService serves the eth_getProof results of testdata/eth_getProof.json, generated
+// by the eth_getProof implementation of geth, at block 100.
+type proofService struct {
+	fixture struct {
+		StateRoot common.Hash     `json:"stateRoot"`
+		Account   json.RawMessage `json:"account"`
+		Absent    json.RawMessage `json:"absent"`
+	}
+}
+
+func newProofService(t *testing.T) *proofService {
+	data, err := os.ReadFile("testdata/eth_getProof.json")
+	assert.NoError(t, err)
+	s := &proofService{}
+	assert.NoError(t, json.Unmarshal(data, &s.fixture))
+	return s
+}
+
+func (s *proofService) GetBlockByNumber(number string, fullBlock bool) *types.Header {
+	if number != "0x64" && number != "latest" {
+		return nil
+	}
+	return &types.Header{Number: big.NewInt(100), Root: s.fixture.StateRoot, Difficulty: big.NewInt(0)}
+}
+
+func (s *proofService) GetProof(account common.Address, keys []string, number string) json.RawMessage {
+	if account == proofAccount {
+		return s.fixture.Account
+	}
+	return s.fixture.Absent
+}
+
+func TestAccountProofVerify(t *testing.T) {
+	service := newProofService(t)
+	ec := newTestClient(t, service, Capabilities{})
+	defer ec.Close()
+
+	tests := []struct {
+		name    string
+		account common.Address
+		keys    []common.Hash
+		root    common.Hash
+		tamper  func(proof *AccountProof)
+		valid   bool
+	}{
+		{name: "valid", account: proofAccount, keys: proofKeys, valid: true},
+		{name: "absent account", account: proofAbsent, keys: proofKeys[:1], valid: true},
+		{name: "tampered balance", account: proofAccount, tamper: func(proof *AccountProof) {
+			proof.Balance = new(big.Int).Add(proof.Balance, big.NewInt(1))
+		}},
+		{name: "tampered nonce", account: proofAccount, tamper: func(proof *AccountProof) {
+			proof.Nonce++
+		}},
+		{name: "tampered code hash", account: proofAccount, tamper: func(proof *AccountProof) {
+			proof.CodeHash = types.EmptyCodeHash
+		}},
+		{name: "tampered storage value", account: proofAccount, keys: proofKeys, tamper: func(proof *AccountProof) {
+			proof.StorageProof[0].Value = big.NewInt(1)
+		}},
+		{name: "tampered missing storage value", account: proofAccount, keys: proofKeys, tamper: func(proof *AccountProof) {
+			proof.StorageProof[1].Value = big.NewInt(1)
+		}},
+		{name: "tampered proof node", account: proofAccount, tamper: func(proof *AccountProof) {
+			node := common.CopyBytes(proof.AccountProof[len(proof.AccountProof)-1])
+			node[len(node)-1] ^= 0x01
+			proof.AccountProof[len(proof.AccountProof)-1] = node
+		}},
+		{name: "wrong state root", account: proofAccount, root: common.HexToHash("0x01")},
+		{name: "absent account with balance", account: proofAbsent, tamper: func(proof *AccountProof) {
+			proof.Balance = big.NewInt(1)
+		}},
+		{name: "absent account with storage", account: proofAbsent, keys: proofKeys[:1], tamper: func(proof *AccountProof) {
+			proof.StorageProof[0].Value = big.NewInt(1)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := ec.ProofAt(context.Background(), tt.account, tt.keys, big.NewInt(100))
+			assert.NoError(t, err)
+			if tt.tamper != nil {
+				tt.tamper(proof)
+			}
+			root := tt.root
+			if root == (common.Hash{}) {
+				root = service.fixture.StateRoot
+			}
+			if tt.valid {
+				assert.NoError(t, proof.Verify(root))
+			} else {
+				assert.Error(t, proof.Verify(root))
+			}
+		})
+	}
+}
+
+func TestVerifiedProofAt(t *testing.T) {
+	ec := newTestClient(t, newProofService(t), Capabilities{})
+	defer ec.Close()
+
+	proof, err := ec.VerifiedProofAt(context.Background(), proofAccount, proofKeys, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), proof.Nonce)
+	assert.Equal(t, big.NewInt(1003), proof.StorageProof[0].Value)
+	assert.Zero(t, proof.StorageProof[1].Value.Sign())
+
+	_, err = ec.VerifiedProofAt(context.Background(), proofAccount, proofKeys[:1], big.NewInt(101))
+	assert.ErrorIs(t, err, ethereum.NotFound)
+
+	// the proof is of another set of keys
+	_, err = ec.VerifiedProofAt(context.Background(), proofAccount, []common.Hash{common.BigToHash(big.NewInt(1))}, nil)
+	assert.Error(t, err)
+}
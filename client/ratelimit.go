@@ -0,0 +1,198 @@
+package client
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	defaultRateLimitBackoff = 1 * time.Second
+	maxRateLimitBackoff     = 5 * time.Minute
+	maxRateLimitRetries     = 3 // rate limited requests retried on the same clients after backoff
+)
+
+// rateLimitErrors contains the error messages returned by well-known providers when the
+// request rate or quota of the account is exceeded.
+var rateLimitErrors = []string{
+	"rate limit",
+	"rate exceeded",
+	"too many requests",
+	"request limit reached",
+	"exceeded the quota",
+	"exceeded its compute units",
+	"capacity exceeded",
+	"throughput exceeded",
+}
+
+var rateLimitCodes = map[int]bool{
+	429:    true,
+	-32029: true,
+	-32090: true,
+}
+
+var retryAfterPattern = regexp.MustCompile(`(?:retry after|try again in) (\d+(?:\.\d+)?) ?(ms|s|sec|second|seconds)?\b`)
+
+// RateLimit is the local request rate limit of an endpoint, a zero RPS means unlimited.
+type RateLimit struct {
+	RPS   float64 // sustained requests per second
+	Burst int     // maximum number of requests sent at once, defaults to RPS rounded up
+}
+
+// tokenBucket throttles requests to a sustained rate with bursts.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.RPS <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = math.Max(1, math.Ceil(limit.RPS))
+	}
+	return &tokenBucket{rate: limit.RPS, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take removes a token from the bucket, if none is available it returns the time until
+// the next one.
+func (b *tokenBucket) take(now time.Time) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second)), false
+}
+
+// retryAfterTransport records the Retry-After header of the rate limited responses.
+type retryAfterTransport struct {
+	base       http.RoundTripper
+	retryAfter *atomic.Int64
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			t.retryAfter.Store(int64(delay))
+		}
+	}
+	return resp, err
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either in seconds or a date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// isRateLimitError reports whether err indicates that the endpoint rejected the request
+// because of its rate limit or quota.
+func isRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rateLimitCodes[rpcErr.ErrorCode()] {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range rateLimitErrors {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitBackoff returns how long the client asked to wait after rejecting a request
+// with err, from the Retry-After header, the error data or the error message.
+func (ec *ETHClient) rateLimitBackoff(err error) time.Duration {
+	delay := time.Duration(ec.retryAfter.Swap(0))
+	var dataErr rpc.DataError
+	if delay == 0 && errors.As(err, &dataErr) {
+		// infura reports {"rate": {"backoff_seconds": 30}}
+		if data, ok := dataErr.ErrorData().(map[string]interface{}); ok {
+			if rate, ok := data["rate"].(map[string]interface{}); ok {
+				data = rate
+			}
+			if seconds, ok := data["backoff_seconds"].(float64); ok {
+				delay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+	if match := retryAfterPattern.FindStringSubmatch(strings.ToLower(err.Error())); delay == 0 && match != nil {
+		value, _ := strconv.ParseFloat(match[1], 64)
+		if match[2] == "ms" {
+			delay = time.Duration(value * float64(time.Millisecond))
+		} else {
+			delay = time.Duration(value * float64(time.Second))
+		}
+	}
+	if delay <= 0 {
+		return defaultRateLimitBackoff
+	}
+	if delay > maxRateLimitBackoff {
+		return maxRateLimitBackoff
+	}
+	return delay
+}
+
+// throttle stops sending requests to the client for the given duration.
+func (p *RpcConnectionPool) throttle(idx int, client *ETHClient, delay time.Duration) {
+	log.Debug("RPC endpoint is rate limited", "url", client.url, "backoff", delay)
+	until := time.Now().Add(delay).UnixNano()
+	for {
+		old := atomic.LoadInt64(&p.throttled[idx])
+		if old >= until || atomic.CompareAndSwapInt64(&p.throttled[idx], old, until) {
+			return
+		}
+	}
+}
+
+// throttleDelay returns how long the client is still throttled by the endpoint.
+func (p *RpcConnectionPool) throttleDelay(idx int, now time.Time) time.Duration {
+	return time.Duration(atomic.LoadInt64(&p.throttled[idx]) - now.UnixNano())
+}
+
+// takeToken consumes a request of the local rate limit of the client, if none is
+// available it returns the time until the next one.
+func (p *RpcConnectionPool) takeToken(idx int, now time.Time) (time.Duration, bool) {
+	if p.limiters[idx] == nil {
+		return 0, true
+	}
+	return p.limiters[idx].take(now)
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	bucket := newTokenBucket(RateLimit{RPS: 10, Burst: 2})
+	bucket.last = now
+	for i := 0; i < 2; i++ {
+		_, ok := bucket.take(now)
+		assert.True(t, ok)
+	}
+	delay, ok := bucket.take(now)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, delay)
+	_, ok = bucket.take(now.Add(100 * time.Millisecond))
+	assert.True(t, ok)
+	assert.Nil(t, newTokenBucket(RateLimit{}))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	delay, ok := parseRetryAfter("3", now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, delay)
+	delay, ok = parseRetryAfter("Mon, 01 Jan 2024 00:00:10 GMT", now)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, delay)
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+type rateLimitError struct {
+	backoff float64
+}
+
+func (e *rateLimitError) Error() string  { return "project ID request rate exceeded" }
+func (e *rateLimitError) ErrorCode() int { return -32005 }
+func (e *rateLimitError) ErrorData() interface{} {
+	return map[string]interface{}{"backoff_seconds": e.backoff}
+}
+
+// limitedGasService rejects the first request with a rate limit error.
+type limitedGasService struct {
+	calls int
+}
+
+func (s *limitedGasService) GasPrice() (*hexutil.Big, error) {
+	if s.calls++; s.calls == 1 {
+		return nil, &rateLimitError{backoff: 0.05}
+	}
+	return (*hexutil.Big)(big.NewInt(1)), nil
+}
+
+func TestPoolHonorsRateLimitBackoff(t *testing.T) {
+	service := &limitedGasService{}
+	pool := NewRpcConnectionPool([]*ETHClient{newTestClient(t, service, Capabilities{})})
+	defer pool.Close()
+
+	start := time.Now()
+	price, err := pool.SuggestGasPrice(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), price)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, 2, service.calls)
+	assert.Equal(t, clientStatusActive, pool.status[0])
+}
+
+// headCountingService counts the eth_blockNumber requests.
+type headCountingService struct {
+	calls atomic.Int32
+}
+
+func (s *headCountingService) BlockNumber() hexutil.Uint64 {
+	s.calls.Add(1)
+	return 100
+}
+
+func TestPoolHeadPollsHonorRateLimit(t *testing.T) {
+	service := &headCountingService{}
+	pool := newRpcConnectionPool([]*ETHClient{newTestClient(t, service, Capabilities{})}, &PoolConfig{RateLimit: RateLimit{RPS: 0.01, Burst: 1}})
+	defer pool.Close()
+
+	// the first poll takes the only token, the next one skips the client
+	assert.Eventually(t, func() bool { return atomic.LoadUint64(&pool.heads[0]) == 100 }, time.Second, 10*time.Millisecond)
+	pool.updateHeads()
+	assert.Equal(t, int32(1), service.calls.Load())
+}
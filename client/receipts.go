@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const rpcMethodNotFoundCode = -32601
+
+// blockReceiptsMethods are the single request methods for block receipts, in order of
+// preference.
+var blockReceiptsMethods = []string{"eth_getBlockReceipts", "parity_getBlockReceipts"}
+
+var methodNotFoundErrors = []string{
+	"method not found",
+	"does not exist/is not available",
+	"method not supported",
+	"unsupported method",
+}
+
+// isMethodNotFoundError reports whether the endpoint does not implement the requested method.
+func isMethodNotFoundError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcMethodNotFoundCode {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range methodNotFoundErrors {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyBlockReceipts checks that the receipts are the ordered receipts of a single block
+// matching the requested number or hash.
+func verifyBlockReceipts(receipts types.Receipts, numberOrHash interface{}) error {
+	for idx, receipt := range receipts {
+		if receipt == nil {
+			return fmt.Errorf("got null receipt at index %d", idx)
+		}
+	}
+	var blockHash common.Hash
+	switch v := numberOrHash.(type) {
+	case common.Hash:
+		blockHash = v
+	case *big.Int:
+		if len(receipts) > 0 {
+			blockHash = receipts[0].BlockHash
+		}
+		if v == nil || v.Sign() < 0 {
+			break
+		}
+		for _, receipt := range receipts {
+			if receipt.BlockNumber == nil || receipt.BlockNumber.Cmp(v) != 0 {
+				return fmt.Errorf("receipt %s does not belong to block %v", receipt.TransactionHash, v)
+			}
+		}
+	}
+	for idx, receipt := range receipts {
+		if receipt.BlockHash != blockHash {
+			return fmt.Errorf("receipt %s does not belong to block %s", receipt.TransactionHash, blockHash)
+		}
+		if receipt.TransactionIndex != uint(idx) {
+			return fmt.Errorf("got receipt of transaction index %d at index %d", receipt.TransactionIndex, idx)
+		}
+	}
+	return nil
+}
+
+// blockReceiptsByTxHashes retrieves the block then the receipts of its transactions, it
+// works on every endpoint.
+func blockReceiptsByTxHashes(ctx context.Context, client rpcCaller, numberOrHash interface{}) (types.Receipts, error) {
+	var (
+		block *types.Block
+		err   error
+	)
+	switch v := numberOrHash.(type) {
+	case common.Hash:
+		block, err = getBlock(ctx, client, "eth_getBlockByHash", v, false)
+	case *big.Int:
+		block, err = getBlock(ctx, client, "eth_getBlockByNumber", toBlockNumArg(v), false)
+	default:
+		err = fmt.Errorf("invalid number or hash argument")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return getBlockReceiptsByHashes(ctx, client, block.Hash(), block.Transactions())
+}
+
+// blockReceipts retrieves the receipts of a block with the first block receipts method
+// the endpoint implements, falling back to fetching the receipts one by one. Methods the
+// endpoint reports as not found are remembered and skipped afterwards.
+func (ec *ETHClient) blockReceipts(ctx context.Context, numberOrHash interface{}) (types.Receipts, error) {
+	arg, err := parseNumberOrHash(numberOrHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, method := range blockReceiptsMethods {
+		if !ec.supportsMethod(method) {
+			continue
+		}
+		var receipts types.Receipts
+		if err := ec.Call(ctx, &receipts, method, arg); err != nil {
+			if isMethodNotFoundError(err) {
+				log.Debug("RPC method not supported", "url", ec.url, "method", method)
+				ec.unsupportedMethods.Store(method, true)
+				continue
+			}
+			return nil, err
+		}
+		if receipts == nil {
+			return nil, ethereum.NotFound
+		}
+		if err := verifyBlockReceipts(receipts, numberOrHash); err != nil {
+			return nil, err
+		}
+		return receipts, nil
+	}
+	return blockReceiptsByTxHashes(ctx, ec, numberOrHash)
+}
+
+func (ec *ETHClient) supportsMethod(method string) bool {
+	_, unsupported := ec.unsupportedMethods.Load(method)
+	return !unsupported
+}
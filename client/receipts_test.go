@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// legacyEthService serves a single block without implementing eth_getBlockReceipts, other
+// blocks are null.
+type legacyEthService struct {
+	header   *types.Header
+	receipts []*types.Receipt
+}
+
+func (s *legacyEthService) GetBlockByNumber(number string, fullBlock bool) (map[string]interface{}, error) {
+	if number != hexutil.EncodeBig(s.header.Number) {
+		return nil, nil
+	}
+	var block map[string]interface{}
+	enc, _ := json.Marshal(s.header)
+	if err := json.Unmarshal(enc, &block); err != nil {
+		return nil, err
+	}
+	txHashes := make([]common.Hash, len(s.receipts))
+	for idx, receipt := range s.receipts {
+		txHashes[idx] = receipt.TransactionHash
+	}
+	block["transactions"] = txHashes
+	return block, nil
+}
+
+func (s *legacyEthService) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	for _, receipt := range s.receipts {
+		if receipt.TransactionHash == hash {
+			return receipt, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestBlockReceiptsFallback(t *testing.T) {
+	header := &types.Header{
+		Number:     big.NewInt(100),
+		Hash:       common.HexToHash("0xb100"),
+		Difficulty: big.NewInt(0),
+	}
+	service := &legacyEthService{header: header}
+	for idx := 0; idx < 3; idx++ {
+		service.receipts = append(service.receipts, &types.Receipt{
+			Status:           types.ReceiptStatusSuccessful,
+			Logs:             []*types.Log{},
+			TransactionHash:  common.BigToHash(big.NewInt(int64(idx + 1))),
+			TransactionIndex: uint(idx),
+			BlockHash:        header.Hash,
+			BlockNumber:      header.Number,
+		})
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	assert.NoError(t, server.RegisterName("eth", service))
+	ec := &ETHClient{url: "inproc", client: rpc.DialInProc(server)}
+	defer ec.Close()
+
+	for i := 0; i < 2; i++ {
+		receipts, err := ec.BlockReceipts(context.Background(), header.Number)
+		assert.NoError(t, err)
+		assert.Len(t, receipts, 3)
+	}
+	assert.False(t, ec.supportsMethod("eth_getBlockReceipts"))
+	assert.False(t, ec.supportsMethod("parity_getBlockReceipts"))
+
+	// a receipt of another block must be rejected
+	service.receipts[1].BlockHash = common.HexToHash("0xb101")
+	_, err := ec.BlockReceipts(context.Background(), header.Number)
+	assert.Error(t, err)
+}
+
+func TestBlockReceiptsUnknownBlock(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(0)}
+	server := rpc.NewServer()
+	defer server.Stop()
+	assert.NoError(t, server.RegisterName("eth", &legacyEthService{header: header}))
+	ec := &ETHClient{url: "inproc", client: rpc.DialInProc(server)}
+	defer ec.Close()
+
+	_, err := ec.BlockByNumber(context.Background(), big.NewInt(101), false)
+	assert.ErrorIs(t, err, ethereum.NotFound)
+	_, err = ec.BlockReceipts(context.Background(), big.NewInt(101))
+	assert.ErrorIs(t, err, ethereum.NotFound)
+}
+
+// nullCaller decodes a null result of every request into the result, like the batch
+// requests of rpc.Client do.
+type nullCaller struct{}
+
+func (c *nullCaller) Call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return json.Unmarshal([]byte("null"), result)
+}
+
+func (c *nullCaller) BatchCall(ctx context.Context, batch []rpc.BatchElem) error {
+	for _, elem := range batch {
+		if err := json.Unmarshal([]byte("null"), elem.Result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGetBlockNullResult(t *testing.T) {
+	_, err := getBlock(context.Background(), &nullCaller{}, "eth_getBlockByNumber", "0x65", false)
+	assert.ErrorIs(t, err, ethereum.NotFound)
+	_, err = blockReceiptsByTxHashes(context.Background(), &nullCaller{}, big.NewInt(101))
+	assert.ErrorIs(t, err, ethereum.NotFound)
+}
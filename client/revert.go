@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+	panicArgs     = abi.Arguments{{Type: mustNewType("uint256")}}
+)
+
+// panicReasons are the meanings of the Panic(uint256) codes emitted by Solidity.
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler inserted panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+func mustNewType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+// RevertError is returned when a call reverts, it holds the revert data and what could be
+// decoded from it.
+type RevertError struct {
+	Data        []byte   // raw revert data, empty if the endpoint did not return it
+	Reason      string   // message of Error(string) reverts
+	PanicCode   *big.Int // code of Panic(uint256) reverts
+	PanicReason string   // meaning of the panic code
+	ErrorName   string   // name of the custom error, set by WithABI
+	ErrorArgs   []interface{}
+
+	err error
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Reason != "":
+		return "execution reverted: " + e.Reason
+	case e.PanicCode != nil:
+		return fmt.Sprintf("execution reverted: panic 0x%x (%s)", e.PanicCode, e.PanicReason)
+	case e.ErrorName != "":
+		args := make([]string, len(e.ErrorArgs))
+		for idx, arg := range e.ErrorArgs {
+			args[idx] = fmt.Sprint(arg)
+		}
+		return fmt.Sprintf("execution reverted: %s(%s)", e.ErrorName, strings.Join(args, ", "))
+	case len(e.Data) >= 4:
+		return fmt.Sprintf("execution reverted: custom error %s", hexutil.Encode(e.Data[:4]))
+	}
+	return "execution reverted"
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.err
+}
+
+// ErrorData returns the revert data as returned by the endpoint.
+func (e *RevertError) ErrorData() interface{} {
+	return hexutil.Encode(e.Data)
+}
+
+// WithABI returns a copy of the error with the custom error of the revert data decoded
+// against the errors of the contract ABI.
+func (e *RevertError) WithABI(contractABI *abi.ABI) *RevertError {
+	cpy := *e
+	if len(e.Data) < 4 || contractABI == nil {
+		return &cpy
+	}
+	for _, abiErr := range contractABI.Errors {
+		if !bytes.Equal(e.Data[:4], abiErr.ID[:4]) {
+			continue
+		}
+		if args, err := abiErr.Inputs.Unpack(e.Data[4:]); err == nil {
+			cpy.ErrorName, cpy.ErrorArgs = abiErr.Name, args
+		}
+		break
+	}
+	return &cpy
+}
+
+// revertData extracts the revert data from the data of a JSON-RPC error, some providers
+// nest it in an object.
+func revertData(data interface{}) ([]byte, bool) {
+	switch v := data.(type) {
+	case string:
+		decoded, err := hexutil.Decode(v)
+		return decoded, err == nil
+	case map[string]interface{}:
+		return revertData(v["data"])
+	}
+	return nil, false
+}
+
+// newRevertError decodes the revert data of a reverted call.
+func newRevertError(data []byte, err error) *RevertError {
+	revertErr := &RevertError{Data: data, err: err}
+	switch {
+	case len(data) >= 4 && bytes.Equal(data[:4], errorSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			revertErr.Reason = reason
+		}
+	case len(data) >= 4 && bytes.Equal(data[:4], panicSelector):
+		if args, err := panicArgs.Unpack(data[4:]); err == nil {
+			revertErr.PanicCode = args[0].(*big.Int)
+			revertErr.PanicReason = "unknown panic code"
+			if revertErr.PanicCode.IsUint64() {
+				if reason, ok := panicReasons[revertErr.PanicCode.Uint64()]; ok {
+					revertErr.PanicReason = reason
+				}
+			}
+		}
+	}
+	return revertErr
+}
+
+// toRevertError turns the error of a reverted call into a RevertError, other errors are
+// returned unchanged.
+func toRevertError(err error) error {
+	if !errors.Is(err, ErrExecutionReverted) {
+		return err
+	}
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := revertData(dataErr.ErrorData()); ok {
+			return newRevertError(data, err)
+		}
+	}
+	return newRevertError(nil, err)
+}
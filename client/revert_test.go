@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const customErrorABI = `[{"inputs":[{"internalType":"uint256","name":"available","type":"uint256"},{"internalType":"uint256","name":"required","type":"uint256"}],"name":"InsufficientBalance","type":"error"}]`
+
+type revertDataError struct {
+	data string
+}
+
+func (e *revertDataError) Error() string          { return "execution reverted" }
+func (e *revertDataError) ErrorCode() int         { return 3 }
+func (e *revertDataError) ErrorData() interface{} { return e.data }
+
+// revertDataService reverts every eth_call with the given revert data.
+type revertDataService struct {
+	data string
+}
+
+func (s *revertDataService) Call(args map[string]interface{}, number string) (hexutil.Bytes, error) {
+	return nil, &revertDataError{s.data}
+}
+
+func callRevert(t *testing.T, data []byte) *RevertError {
+	ec := newTestClient(t, &revertDataService{hexutil.Encode(data)}, Capabilities{})
+	defer ec.Close()
+	_, err := ec.CallContract(context.Background(), ethereum.CallMsg{}, nil)
+	assert.ErrorIs(t, err, ErrExecutionReverted)
+	revertErr, ok := err.(*RevertError)
+	assert.True(t, ok)
+	assert.Equal(t, data, revertErr.Data)
+	return revertErr
+}
+
+func TestRevertErrorReason(t *testing.T) {
+	stringType := abi.Arguments{{Type: mustNewType("string")}}
+	packed, _ := stringType.Pack("not owner")
+	revertErr := callRevert(t, append(common.CopyBytes(errorSelector), packed...))
+	assert.Equal(t, "not owner", revertErr.Reason)
+	assert.Equal(t, "execution reverted: not owner", revertErr.Error())
+}
+
+func TestRevertErrorPanic(t *testing.T) {
+	packed, _ := panicArgs.Pack(big.NewInt(0x11))
+	revertErr := callRevert(t, append(common.CopyBytes(panicSelector), packed...))
+	assert.Equal(t, big.NewInt(0x11), revertErr.PanicCode)
+	assert.Equal(t, "arithmetic underflow or overflow", revertErr.PanicReason)
+}
+
+func TestRevertErrorCustom(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(customErrorABI))
+	assert.NoError(t, err)
+	abiErr := parsed.Errors["InsufficientBalance"]
+	packed, _ := abiErr.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	revertErr := callRevert(t, append(common.CopyBytes(abiErr.ID[:4]), packed...))
+	assert.Equal(t, "execution reverted: custom error "+hexutil.Encode(abiErr.ID[:4]), revertErr.Error())
+
+	decoded := revertErr.WithABI(&parsed)
+	assert.Equal(t, "InsufficientBalance", decoded.ErrorName)
+	assert.Equal(t, []interface{}{big.NewInt(1), big.NewInt(2)}, decoded.ErrorArgs)
+	assert.Equal(t, "execution reverted: InsufficientBalance(1, 2)", decoded.Error())
+}
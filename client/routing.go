@@ -0,0 +1,165 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// archiveStateDepth is the number of recent blocks whose state is kept by pruned nodes.
+const archiveStateDepth = 128
+
+// requirement is the set of capabilities a client needs to serve a request.
+type requirement uint8
+
+const (
+	requireArchive requirement = 1 << iota
+	requireDebug
+	requireTrace
+)
+
+// stateMethodBlockArgs maps the methods reading the state at a given block to the position
+// of their block argument.
+var stateMethodBlockArgs = map[string]int{
+	"eth_getBalance":          1,
+	"eth_getCode":             1,
+	"eth_getTransactionCount": 1,
+	"eth_getStorageAt":        2,
+	"eth_getProof":            2,
+	"eth_call":                1,
+	"eth_estimateGas":         1,
+	"eth_createAccessList":    1,
+	"eth_simulateV1":          1,
+}
+
+// isHistoricalBlockArg reports whether the block argument refers to a block by number,
+// whose state is not guaranteed to be kept by pruned nodes. Block tags and hashes are
+// served by every node.
+func isHistoricalBlockArg(arg interface{}) bool {
+	tag, ok := arg.(string)
+	if !ok {
+		return false
+	}
+	if tag == "earliest" {
+		return true
+	}
+	_, err := hexutil.DecodeUint64(tag)
+	return err == nil
+}
+
+// blockMethodArgs maps the methods reading a block by number to the position of their
+// block argument.
+var blockMethodArgs = map[string]int{
+	"eth_getBlockByNumber":                    0,
+	"eth_getBlockReceipts":                    0,
+	"eth_getBlockTransactionCountByNumber":    0,
+	"eth_getTransactionByBlockNumberAndIndex": 0,
+	"eth_getUncleByBlockNumberAndIndex":       0,
+	"eth_feeHistory":                          1,
+	"parity_getBlockReceipts":                 0,
+	"debug_traceBlockByNumber":                0,
+	"trace_block":                             0,
+	"trace_replayBlockTransactions":           0,
+}
+
+// route holds what a request needs from the client serving it.
+type route struct {
+	requires requirement // archive is only required for blocks older than archiveStateDepth
+	latest   bool        // reads the latest block
+	number   uint64      // highest block number read, zero if none
+}
+
+func (r *route) addBlockArg(arg interface{}) {
+	tag, ok := arg.(string)
+	if !ok {
+		return
+	}
+	if tag == "latest" || tag == "pending" {
+		r.latest = true
+	} else if number, err := hexutil.DecodeUint64(tag); err == nil && number > r.number {
+		r.number = number
+	}
+}
+
+// merge combines the routes of the requests sent in the same batch.
+func (r route) merge(other route) route {
+	r.requires |= other.requires
+	r.latest = r.latest || other.latest
+	if other.number > r.number {
+		r.number = other.number
+	}
+	return r
+}
+
+// routeRequest returns what the request needs from the client serving it.
+func routeRequest(method string, args []interface{}) route {
+	var r route
+	switch {
+	case strings.HasPrefix(method, "debug_"):
+		r.requires = requireDebug
+	case strings.HasPrefix(method, "trace_"):
+		r.requires = requireTrace
+	}
+	if pos, ok := stateMethodBlockArgs[method]; ok && pos < len(args) {
+		if isHistoricalBlockArg(args[pos]) {
+			r.requires |= requireArchive
+		}
+		r.addBlockArg(args[pos])
+	} else if pos, ok := blockMethodArgs[method]; ok && pos < len(args) {
+		r.addBlockArg(args[pos])
+	}
+	switch method {
+	case "eth_blockNumber":
+		r.latest = true
+	case "eth_getLogs":
+		if len(args) > 0 {
+			if filter, ok := args[0].(map[string]interface{}); ok {
+				r.addBlockArg(filter["toBlock"])
+			}
+		}
+	}
+	return r
+}
+
+func (r requirement) satisfiedBy(caps Capabilities) bool {
+	return (r&requireArchive == 0 || caps.Archive) &&
+		(r&requireDebug == 0 || caps.Debug) &&
+		(r&requireTrace == 0 || caps.Trace)
+}
+
+func (r requirement) String() string {
+	var names []string
+	if r&requireArchive != 0 {
+		names = append(names, "archive")
+	}
+	if r&requireDebug != 0 {
+		names = append(names, "debug")
+	}
+	if r&requireTrace != 0 {
+		names = append(names, "trace")
+	}
+	return strings.Join(names, ",")
+}
+
+// preferCapable reorders the clients so that the ones known to have the capabilities
+// required by the request come first. The other clients follow as their capabilities may
+// be unknown, a client unable to serve the request fails it and the next one is tried.
+func (p *RpcConnectionPool) preferCapable(order []int, r route) []int {
+	requires := r.requires
+	if requires&requireArchive != 0 && r.number+archiveStateDepth >= p.bestHead() {
+		requires &^= requireArchive
+	}
+	if requires == 0 {
+		return order
+	}
+	capable := make([]int, 0, len(order))
+	var others []int
+	for _, idx := range order {
+		if requires.satisfiedBy(p.clients[idx].Capabilities()) {
+			capable = append(capable, idx)
+		} else {
+			others = append(others, idx)
+		}
+	}
+	return append(capable, others...)
+}
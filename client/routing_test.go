@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type archiveEthService struct{}
+
+func (s *archiveEthService) GetBalance(account common.Address, number string) *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1))
+}
+
+func newTestClient(t *testing.T, service interface{}, caps Capabilities) *ETHClient {
+	ec := newNamespaceTestClient(t, "eth", service)
+	ec.caps.Store(&caps)
+	return ec
+}
+
+// newNamespaceTestClient returns a client of an in-process server serving the service
+// under the given namespace.
+func newNamespaceTestClient(t *testing.T, namespace string, service interface{}) *ETHClient {
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	assert.NoError(t, server.RegisterName(namespace, service))
+	return &ETHClient{url: "http://inproc", client: rpc.DialInProc(server)}
+}
+
+func TestRouteRequest(t *testing.T) {
+	assert.Equal(t, route{requires: requireArchive, number: 16}, routeRequest("eth_call", []interface{}{nil, "0x10"}))
+	assert.Equal(t, route{requires: requireArchive}, routeRequest("eth_getStorageAt", []interface{}{nil, nil, "earliest"}))
+	assert.Equal(t, route{latest: true}, routeRequest("eth_call", []interface{}{nil, "latest"}))
+	assert.Equal(t, route{number: 16}, routeRequest("eth_getBlockByNumber", []interface{}{"0x10", false}))
+	assert.Equal(t, route{number: 32}, routeRequest("eth_getLogs", []interface{}{map[string]interface{}{"fromBlock": "0x10", "toBlock": "0x20"}}))
+	assert.Equal(t, route{requires: requireDebug}, routeRequest("debug_traceTransaction", nil))
+	assert.Equal(t, route{requires: requireTrace, latest: true}, routeRequest("trace_block", []interface{}{"latest"}))
+}
+
+func TestPoolRoutesHistoricalState(t *testing.T) {
+	pruned := newTestClient(t, &prunedEthService{}, Capabilities{})
+	archive := newTestClient(t, &archiveEthService{}, Capabilities{Archive: true})
+	pool := NewRpcConnectionPool([]*ETHClient{pruned, archive})
+	defer pool.Close()
+	pool.updateHeads()
+
+	assert.Equal(t, []int{1, 0}, pool.preferCapable([]int{0, 1}, route{requires: requireArchive, number: 1}))
+	assert.Equal(t, []int{0, 1}, pool.preferCapable([]int{0, 1}, route{requires: requireArchive, number: 49_900}))
+	balance, err := pool.BalanceAt(context.Background(), common.Address{}, big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), balance)
+	assert.Equal(t, clientStatusActive, pool.status[0])
+
+	err = pool.Call(context.Background(), nil, "debug_traceTransaction", common.Hash{})
+	assert.Error(t, err)
+}
+
+// headerService serves the headers up to its head, or null for every block if it is not
+// synced.
+type headerService struct {
+	head   uint64
+	synced bool
+}
+
+func (s *headerService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.head)
+}
+
+func (s *headerService) GetBlockByNumber(number hexutil.Uint64, fullBlock bool) *types.Header {
+	if !s.synced || uint64(number) > s.head {
+		return nil
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(uint64(number)), Difficulty: big.NewInt(0)}
+}
+
+// recentStateService emulates a pruned node keeping the state of the recent blocks only.
+type recentStateService struct {
+	head uint64
+}
+
+func (s *recentStateService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.head)
+}
+
+func (s *recentStateService) GetBalance(account common.Address, number hexutil.Uint64) (*hexutil.Big, error) {
+	if uint64(number)+archiveStateDepth < s.head {
+		return nil, errors.New("missing trie node")
+	}
+	return (*hexutil.Big)(big.NewInt(1)), nil
+}
+
+type debugService struct{}
+
+func (s *debugService) TraceTransaction(hash common.Hash) map[string]interface{} {
+	return map[string]interface{}{"gas": 21000}
+}
+
+func TestPoolFallsBackToClientsWithoutCapabilities(t *testing.T) {
+	// the capabilities of clients created with NewClient are never probed
+	unprobed := newTestClient(t, &recentStateService{head: 1000}, Capabilities{})
+	unprobed.caps.Store(nil)
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	assert.NoError(t, server.RegisterName("eth", &recentStateService{head: 1000}))
+	assert.NoError(t, server.RegisterName("debug", &debugService{}))
+	tracing := &ETHClient{url: "http://inproc", client: rpc.DialInProc(server)}
+	pool := NewRpcConnectionPool([]*ETHClient{unprobed, tracing})
+	defer pool.Close()
+	pool.updateHeads()
+
+	for _, number := range []int64{1000, 900} {
+		balance, err := pool.BalanceAt(context.Background(), common.Address{}, big.NewInt(number))
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(1), balance)
+	}
+	_, err := pool.BalanceAt(context.Background(), common.Address{}, big.NewInt(1))
+	assert.ErrorIs(t, err, ErrHeaderNotFound)
+	assert.Equal(t, clientStatusActive, pool.status[0])
+
+	var trace map[string]interface{}
+	assert.NoError(t, pool.Call(context.Background(), &trace, "debug_traceTransaction", common.Hash{}))
+	assert.Equal(t, float64(21000), trace["gas"])
+}
+
+func TestPoolSkipsLaggingClients(t *testing.T) {
+	lagging := newTestClient(t, &headerService{head: 90, synced: true}, Capabilities{})
+	synced := newTestClient(t, &headerService{head: 100, synced: true}, Capabilities{})
+	pool := NewRpcConnectionPool([]*ETHClient{lagging, synced})
+	defer pool.Close()
+	pool.updateHeads()
+
+	assert.True(t, pool.isLagging(0, route{latest: true}))
+	assert.True(t, pool.isLagging(0, route{number: 95}))
+	assert.False(t, pool.isLagging(0, route{number: 80}))
+	assert.False(t, pool.isLagging(1, route{latest: true}))
+
+	header, err := pool.HeaderByNumber(context.Background(), big.NewInt(95))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(95), header.Number.Uint64())
+}
+
+func TestPoolRetriesNullResult(t *testing.T) {
+	unsynced := newTestClient(t, &headerService{head: 100}, Capabilities{})
+	synced := newTestClient(t, &headerService{head: 100, synced: true}, Capabilities{})
+	pool := NewRpcConnectionPool([]*ETHClient{unsynced, synced})
+	defer pool.Close()
+	pool.updateHeads()
+
+	header, err := pool.HeaderByNumber(context.Background(), big.NewInt(50))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(50), header.Number.Uint64())
+
+	_, err = pool.HeaderByNumber(context.Background(), big.NewInt(101))
+	assert.ErrorIs(t, err, ethereum.NotFound)
+}
@@ -36,16 +36,35 @@ func toCallArg(msg ethereum.CallMsg) interface{} {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
 	return arg
 }
 
+// blockNumberTags maps the negative block numbers accepted in place of a block number to
+// their block tag.
+var blockNumberTags = map[int64]string{
+	-1: "pending",
+	-2: "latest",
+	-3: "finalized",
+	-4: "safe",
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
 	}
-	pending := big.NewInt(-1)
-	if number.Cmp(pending) == 0 {
-		return "pending"
+	if number.Sign() < 0 && number.IsInt64() {
+		if tag, ok := blockNumberTags[number.Int64()]; ok {
+			return tag
+		}
 	}
 	return hexutil.EncodeBig(number)
 }
@@ -80,6 +99,8 @@ func getBlock(ctx context.Context, client rpcCaller, method string, numOrHash in
 	var header *types.Header
 	if err := json.Unmarshal(raw, &header); err != nil {
 		return nil, err
+	} else if header == nil {
+		return nil, ethereum.NotFound
 	}
 	if fullBlock {
 		var resp struct {
@@ -112,6 +133,71 @@ func getBlock(ctx context.Context, client rpcCaller, method string, numOrHash in
 	}
 }
 
+func getHeader(ctx context.Context, client rpcCaller, method string, numOrHash interface{}) (*types.Header, error) {
+	var header *types.Header
+	if err := client.Call(ctx, &header, method, numOrHash, false); err != nil {
+		return nil, err
+	} else if header == nil {
+		return nil, ethereum.NotFound
+	}
+	return header, nil
+}
+
+func headerByNumber(ctx context.Context, client rpcCaller, number *big.Int) (*types.Header, error) {
+	header, err := getHeader(ctx, client, "eth_getBlockByNumber", toBlockNumArg(number))
+	if err != nil {
+		return nil, err
+	}
+	if number != nil && number.Sign() >= 0 && header.Number.Cmp(number) != 0 {
+		return nil, fmt.Errorf("got wrong header number %v, expected %v", header.Number, number)
+	}
+	return header, nil
+}
+
+func headerByHash(ctx context.Context, client rpcCaller, hash common.Hash) (*types.Header, error) {
+	header, err := getHeader(ctx, client, "eth_getBlockByHash", hash)
+	if err != nil {
+		return nil, err
+	}
+	if header.Hash != hash {
+		return nil, fmt.Errorf("got wrong header for hash %s", hash)
+	}
+	return header, nil
+}
+
+// headersByRange retrieves the headers of blocks [from, to] using batch requests, the
+// headers are verified to form a chain.
+func headersByRange(ctx context.Context, client rpcCaller, from, to uint64) ([]*types.Header, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid header range [%d, %d]", from, to)
+	}
+	headers := make([]*types.Header, to-from+1)
+	batch := make([]rpc.BatchElem, len(headers))
+	for idx := range headers {
+		batch[idx] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(from + uint64(idx)), false},
+			Result: &headers[idx],
+		}
+	}
+	if err := batchCallChunked(ctx, client, batch); err != nil {
+		return nil, err
+	}
+	for idx, header := range headers {
+		number := from + uint64(idx)
+		if header == nil {
+			return nil, fmt.Errorf("got null header for number %d: %w", number, ethereum.NotFound)
+		}
+		if !header.Number.IsUint64() || header.Number.Uint64() != number {
+			return nil, fmt.Errorf("got wrong header number %v, expected %d", header.Number, number)
+		}
+		if idx > 0 && header.ParentHash != headers[idx-1].Hash {
+			return nil, fmt.Errorf("header %d is not a child of header %d", number, number-1)
+		}
+	}
+	return headers, nil
+}
+
 func getBlockUncles(ctx context.Context, client rpcCaller, blockHash common.Hash, hashes []common.Hash) ([]*types.Header, error) {
 	if len(hashes) == 0 {
 		return nil, nil
@@ -171,28 +257,20 @@ func batchGetTransactionReceipt(ctx context.Context, client rpcCaller, txHashes
 }
 
 func getBlockReceiptsByHashes(ctx context.Context, client rpcCaller, blockHash common.Hash, txHashes []common.Hash) (types.Receipts, error) {
-	if len(txHashes) <= rpcRequestBatchSize {
-		return batchGetTransactionReceipt(ctx, client, txHashes)
-	} else {
-		ret := make([]*types.Receipt, 0, len(txHashes))
-		batchCount := (len(txHashes) + rpcRequestBatchSize - 1) / rpcRequestBatchSize
-		for i := 0; i < batchCount; i++ {
-			start := i * rpcRequestBatchSize
-			end := start + rpcRequestBatchSize
-			if end > len(txHashes) {
-				end = len(txHashes)
-			}
-			receipts, err := batchGetTransactionReceipt(ctx, client, txHashes[start:end])
-			if err != nil {
-				return nil, err
-			}
-			ret = append(ret, receipts...)
-		}
-		for _, receipt := range ret {
-			if receipt.BlockHash != blockHash {
-				return nil, fmt.Errorf("receipt %s does not belong to block %s", receipt.TransactionHash, blockHash)
-			}
-		}
-		return ret, nil
+	ret := make(types.Receipts, 0, len(txHashes))
+	for start := 0; start < len(txHashes); start += rpcRequestBatchSize {
+		end := start + rpcRequestBatchSize
+		if end > len(txHashes) {
+			end = len(txHashes)
+		}
+		receipts, err := batchGetTransactionReceipt(ctx, client, txHashes[start:end])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, receipts...)
+	}
+	if err := verifyBlockReceipts(ret, blockHash); err != nil {
+		return nil, err
 	}
+	return ret, nil
 }
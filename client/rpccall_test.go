@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// chainHeaderService serves a chain of headers, the header at a number can be replaced
+// to emulate a misbehaving endpoint.
+type chainHeaderService struct {
+	headers  []*types.Header
+	replaced map[uint64]*types.Header
+}
+
+func newChainHeaderService(length int) *chainHeaderService {
+	s := &chainHeaderService{replaced: make(map[uint64]*types.Header)}
+	var parent common.Hash
+	for number := 0; number < length; number++ {
+		header := &types.Header{
+			Number:     big.NewInt(int64(number)),
+			Difficulty: big.NewInt(0),
+			Hash:       common.BigToHash(big.NewInt(int64(number + 1000))),
+			ParentHash: parent,
+		}
+		s.headers = append(s.headers, header)
+		parent = header.Hash
+	}
+	return s
+}
+
+func (s *chainHeaderService) GetBlockByNumber(number hexutil.Uint64, fullBlock bool) *types.Header {
+	if header, ok := s.replaced[uint64(number)]; ok {
+		return header
+	}
+	if uint64(number) >= uint64(len(s.headers)) {
+		return nil
+	}
+	return s.headers[number]
+}
+
+func (s *chainHeaderService) GetBlockByHash(hash common.Hash, fullBlock bool) *types.Header {
+	for number, header := range s.headers {
+		if header.Hash == hash {
+			return s.GetBlockByNumber(hexutil.Uint64(number), fullBlock)
+		}
+	}
+	return nil
+}
+
+func TestHeadersByRange(t *testing.T) {
+	chain := newChainHeaderService(10)
+	ec := newTestClient(t, chain, Capabilities{})
+	defer ec.Close()
+
+	headers, err := ec.HeadersByRange(context.Background(), 2, 7)
+	assert.NoError(t, err)
+	assert.Len(t, headers, 6)
+	for idx, header := range headers {
+		assert.Equal(t, chain.headers[2+idx].Hash, header.Hash)
+	}
+
+	_, err = ec.HeadersByRange(context.Background(), 7, 2)
+	assert.Error(t, err)
+	_, err = ec.HeadersByRange(context.Background(), 8, 12)
+	assert.ErrorIs(t, err, ethereum.NotFound)
+}
+
+func TestHeadersByRangeRejectsInvalidChain(t *testing.T) {
+	tests := []struct {
+		name   string
+		header *types.Header
+		err    string
+	}{
+		{"wrong number", &types.Header{Number: big.NewInt(9), Difficulty: big.NewInt(0)}, "wrong header number"},
+		{"wrong parent", &types.Header{Number: big.NewInt(5), Difficulty: big.NewInt(0), ParentHash: common.HexToHash("0x01")}, "not a child"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newChainHeaderService(10)
+			chain.replaced[5] = tt.header
+			ec := newTestClient(t, chain, Capabilities{})
+			defer ec.Close()
+
+			_, err := ec.HeadersByRange(context.Background(), 2, 7)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestHeaderByHash(t *testing.T) {
+	chain := newChainHeaderService(10)
+	ec := newTestClient(t, chain, Capabilities{})
+	defer ec.Close()
+
+	header, err := ec.HeaderByHash(context.Background(), chain.headers[3].Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), header.Number.Uint64())
+
+	_, err = ec.HeaderByHash(context.Background(), common.HexToHash("0x01"))
+	assert.ErrorIs(t, err, ethereum.NotFound)
+
+	// the endpoint answers with another header than the requested one
+	chain.replaced[3] = chain.headers[4]
+	_, err = ec.HeaderByHash(context.Background(), chain.headers[3].Hash)
+	assert.ErrorContains(t, err, "wrong header")
+}
@@ -2,7 +2,9 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/khanghh/ethcore/types"
@@ -19,32 +22,149 @@ import (
 const (
 	rpcConnectionCooldown = 1 * time.Minute
 	rpcDialTimeout        = 5 * time.Second
+	defaultMaxInFlight    = 8
+)
+
+const (
+	clientStatusActive int64 = iota
+	clientStatusCooldown
+	clientStatusEvicted // the client is never used again
 )
 
 // RpcConnectionPool implements RemoteChainReader interface. It picks an ETHClient from pool
-// to make RPC request, if client reate limit reached, it will put the client into cooldown
+// to make RPC request, if client reate limit reached, it will put the client into cooldown.
+// Each client serves at most MaxInFlight requests at once, requests wait for a free slot
+// when every client is saturated.
 type RpcConnectionPool struct {
-	clients []*ETHClient // List of RPC clients
-	status  []int64      // Client status
-	quitCh  chan struct{}
+	clients   []*ETHClient // List of RPC clients
+	status    []int64      // Client status
+	broadcast atomic.Bool  // Send transactions to all healthy clients
+	chainID   *big.Int     // Expected chain id, clients of other chains are evicted
+	networkID *big.Int     // Expected network id
+
+	heads       []uint64       // Latest block number of each client
+	maxHeadLag  uint64         // Maximum number of blocks a client can be behind to serve recent blocks
+	inflight    []int64        // Number of requests being served by each client
+	maxInFlight int64          // Maximum number of requests served by a client at once
+	latency     []int64        // Moving average of the request latency of each client, in nanoseconds
+	strategy    Strategy       // Order in which clients are tried, connect latency order if nil
+	limiters    []*tokenBucket // Local rate limit of each client, nil if unlimited
+	throttled   []int64        // Time until which each client asked to not be sent requests, in unix nanoseconds
+
+	slotMu sync.Mutex
+	slotCh chan struct{} // closed when a slot is released or a client recovers
+	quitCh chan struct{}
+}
+
+// PoolConfig holds the options of RpcConnectionPool.
+type PoolConfig struct {
+	ChainID     *big.Int // expected chain id, not checked if nil
+	NetworkID   *big.Int // expected net_version, not checked if nil
+	MaxHeadLag  uint64   // clients further behind the best head are skipped for recent blocks
+	Strategy    Strategy // load balancing strategy, clients are tried in connect latency order if nil
+	MaxInFlight int      // maximum number of requests served by a client at once
+
+	RateLimit          RateLimit            // local rate limit of every endpoint
+	EndpointRateLimits map[string]RateLimit // local rate limits overriding RateLimit by endpoint url
+}
+
+// setStatus changes the status of the client unless it is evicted.
+func (p *RpcConnectionPool) setStatus(idx int, status int64) bool {
+	for {
+		old := atomic.LoadInt64(&p.status[idx])
+		if old == clientStatusEvicted {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&p.status[idx], old, status) {
+			if status == clientStatusActive {
+				p.notifySlot()
+			}
+			return true
+		}
+	}
+}
+
+func (p *RpcConnectionPool) isHealthy(idx int) bool {
+	return atomic.LoadInt64(&p.status[idx]) == clientStatusActive
+}
+
+// slotReleased returns a channel that is closed the next time a slot may become free.
+func (p *RpcConnectionPool) slotReleased() <-chan struct{} {
+	p.slotMu.Lock()
+	defer p.slotMu.Unlock()
+	return p.slotCh
+}
+
+func (p *RpcConnectionPool) notifySlot() {
+	p.slotMu.Lock()
+	close(p.slotCh)
+	p.slotCh = make(chan struct{})
+	p.slotMu.Unlock()
+}
+
+// acquire takes a request slot of the client, it fails if the client is saturated.
+func (p *RpcConnectionPool) acquire(idx int) bool {
+	for {
+		n := atomic.LoadInt64(&p.inflight[idx])
+		if n >= p.maxInFlight {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&p.inflight[idx], n, n+1) {
+			return true
+		}
+	}
+}
+
+// release returns the request slot of the client.
+func (p *RpcConnectionPool) release(idx int) {
+	atomic.AddInt64(&p.inflight[idx], -1)
+	p.notifySlot()
 }
 
 func (p *RpcConnectionPool) cooldown(idx int, client *ETHClient) {
+	// concurrent failures of the same client start a single cooldown
+	if !atomic.CompareAndSwapInt64(&p.status[idx], clientStatusActive, clientStatusCooldown) {
+		return
+	}
+	p.recover(idx, client, true)
+}
+
+// suspend puts a connected client whose chain could not be verified yet into cooldown, it
+// is activated once its chain is verified.
+func (p *RpcConnectionPool) suspend(idx int, client *ETHClient) {
+	if atomic.CompareAndSwapInt64(&p.status[idx], clientStatusActive, clientStatusCooldown) {
+		go p.recover(idx, client, false)
+	}
+}
+
+// recover activates the client in cooldown again once it serves the expected chain,
+// reconnecting it first if requested.
+func (p *RpcConnectionPool) recover(idx int, client *ETHClient, reconnect bool) {
 	for {
 		select {
 		case <-p.quitCh:
 			return
 		case <-time.After(rpcConnectionCooldown):
-			if err := client.connect(context.Background()); err != nil {
-				log.Warn("Failed to reconnect to RPC", "url", client.url, "error", err)
-			} else {
-				atomic.StoreInt64(&p.status[idx], 0)
+			if reconnect {
+				if err := client.connect(context.Background()); err != nil {
+					log.Warn("Failed to reconnect to RPC", "url", client.url, "error", err)
+					continue
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), rpcRequestTimeout)
+			err := p.verifyChain(ctx, idx, client)
+			cancel()
+			if _, mismatch := err.(*ChainMismatchError); mismatch {
 				return
+			} else if err != nil {
+				log.Warn("Failed to verify chain of RPC", "url", client.url, "error", err)
+				continue
 			}
+			p.setStatus(idx, clientStatusActive)
+			return
 		}
 	}
 }
-
 func (p *RpcConnectionPool) Close() {
 	close(p.quitCh)
 	for _, client := range p.clients {
@@ -56,6 +176,14 @@ func (p *RpcConnectionPool) Size() int {
 	return len(p.clients)
 }
 
+func (p *RpcConnectionPool) ChainID(ctx context.Context) (*big.Int, error) {
+	var result hexutil.Big
+	if err := p.Call(ctx, &result, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&result), nil
+}
+
 func (p *RpcConnectionPool) NetworkID(ctx context.Context) (*big.Int, error) {
 	version := new(big.Int)
 	var ver string
@@ -80,6 +208,18 @@ func (p *RpcConnectionPool) BlockNumber(ctx context.Context) (*big.Int, error) {
 	return hexutil.DecodeBig(result)
 }
 
+func (p *RpcConnectionPool) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return headerByHash(ctx, p, hash)
+}
+
+func (p *RpcConnectionPool) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return headerByNumber(ctx, p, number)
+}
+
+func (p *RpcConnectionPool) HeadersByRange(ctx context.Context, from, to uint64) ([]*types.Header, error) {
+	return headersByRange(ctx, p, from, to)
+}
+
 func (p *RpcConnectionPool) BlockByHash(ctx context.Context, hash common.Hash, fullBlock bool) (*types.Block, error) {
 	return getBlock(ctx, p, "eth_getBlockByHash", hash, fullBlock)
 }
@@ -110,9 +250,13 @@ func (p *RpcConnectionPool) BlockReceipts(ctx context.Context, numberOrHash inte
 	if err != nil {
 		return nil, err
 	}
-	var result types.Receipts
-	err = p.Call(ctx, &result, "eth_getBlockReceipts", numberOrHashArg)
-	return result, err
+	var receipts types.Receipts
+	r := routeRequest("eth_getBlockReceipts", []interface{}{numberOrHashArg})
+	err = p.execute(ctx, "eth_getBlockReceipts", r, func(client *ETHClient) (err error) {
+		receipts, err = client.BlockReceipts(ctx, numberOrHash)
+		return err
+	})
+	return receipts, err
 }
 
 func (p *RpcConnectionPool) CodeAt(ctx context.Context, account common.Address, number *big.Int) ([]byte, error) {
@@ -121,64 +265,291 @@ func (p *RpcConnectionPool) CodeAt(ctx context.Context, account common.Address,
 	return result, err
 }
 
+func (p *RpcConnectionPool) BalanceAt(ctx context.Context, account common.Address, number *big.Int) (*big.Int, error) {
+	return balanceAt(ctx, p, account, number)
+}
+
+func (p *RpcConnectionPool) BalancesAt(ctx context.Context, accounts []common.Address, number *big.Int) ([]*big.Int, error) {
+	return balancesAt(ctx, p, accounts, number)
+}
+
+func (p *RpcConnectionPool) NonceAt(ctx context.Context, account common.Address, number *big.Int) (uint64, error) {
+	return nonceAt(ctx, p, account, number)
+}
+
+func (p *RpcConnectionPool) NoncesAt(ctx context.Context, accounts []common.Address, number *big.Int) ([]uint64, error) {
+	return noncesAt(ctx, p, accounts, number)
+}
+
+func (p *RpcConnectionPool) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return pendingNonceAt(ctx, p, account)
+}
+
+func (p *RpcConnectionPool) StorageAt(ctx context.Context, account common.Address, key common.Hash, number *big.Int) ([]byte, error) {
+	return storageAt(ctx, p, account, key, number)
+}
+
+func (p *RpcConnectionPool) StoragesAt(ctx context.Context, account common.Address, keys []common.Hash, number *big.Int) ([][]byte, error) {
+	return storagesAt(ctx, p, account, keys, number)
+}
+
+func (p *RpcConnectionPool) ProofAt(ctx context.Context, account common.Address, storageKeys []common.Hash, number *big.Int) (*AccountProof, error) {
+	return getProof(ctx, p, account, storageKeys, number)
+}
+
+func (p *RpcConnectionPool) VerifiedProofAt(ctx context.Context, account common.Address, storageKeys []common.Hash, number *big.Int) (*AccountProof, error) {
+	return getVerifiedProof(ctx, p, account, storageKeys, number)
+}
+
 func (p *RpcConnectionPool) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
 	var hex hexutil.Bytes
 	err := p.Call(ctx, &hex, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
 	if err != nil {
-		return nil, err
+		return nil, toRevertError(err)
 	}
 	return hex, nil
 }
 
-func (p *RpcConnectionPool) Call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
-	var err error
-	for idx, client := range p.clients {
-		if !atomic.CompareAndSwapInt64(&p.status[idx], 0, 1) {
-			continue
-		}
-		if err = client.Call(ctx, result, method, args...); err != nil {
+func (p *RpcConnectionPool) CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, overrides StateOverride, blockOverrides *BlockOverrides) ([]byte, error) {
+	return callContractWithOverrides(ctx, p, msg, blockNumber, overrides, blockOverrides)
+}
+
+func (p *RpcConnectionPool) CreateAccessList(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (*types.AccessList, uint64, string, error) {
+	return createAccessList(ctx, p, msg, blockNumber)
+}
+
+func (p *RpcConnectionPool) FilterLogs(ctx context.Context, q ethereum.FilterQuery) (types.Logs, error) {
+	return filterLogs(ctx, p, q)
+}
+
+func (p *RpcConnectionPool) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return estimateGas(ctx, p, msg)
+}
+
+func (p *RpcConnectionPool) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return suggestGasPrice(ctx, p)
+}
+
+func (p *RpcConnectionPool) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return suggestGasTipCap(ctx, p)
+}
+
+func (p *RpcConnectionPool) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return feeHistory(ctx, p, blockCount, lastBlock, rewardPercentiles)
+}
+
+func (p *RpcConnectionPool) SendTransaction(ctx context.Context, tx *gethtypes.Transaction) error {
+	return sendTransaction(ctx, p, tx)
+}
+
+func (p *RpcConnectionPool) SendRawTransaction(ctx context.Context, rawTx []byte) (common.Hash, error) {
+	if p.broadcast.Load() {
+		return p.BroadcastRawTransaction(ctx, rawTx)
+	}
+	return sendRawTransaction(ctx, p, rawTx)
+}
+
+// execute runs fn with the clients not lagging behind the request in the order of the pool
+// strategy, clients known to have the capabilities it requires first, failing over to the
+// next client on error. If the remaining clients are all
+// saturated or rate limited, it waits for one of them until the context is done.
+func (p *RpcConnectionPool) execute(ctx context.Context, method string, r route, fn func(client *ETHClient) error) error {
+	var (
+		err         error
+		capable     bool
+		tried       = make([]bool, len(p.clients))
+		rateLimited int
+		order       = p.preferCapable(p.order(ctx), r) // stateful strategies advance once per request
+	)
+	for {
+		var (
+			released  = p.slotReleased()
+			saturated = false
+			retryIn   time.Duration // time until a rate limited client can be used again
+			now       = time.Now()
+		)
+		for _, idx := range order {
+			client := p.clients[idx]
+			if tried[idx] || p.isLagging(idx, r) {
+				continue
+			}
+			capable = true
+			if !p.isHealthy(idx) {
+				continue
+			}
+			if delay := p.throttleDelay(idx, now); delay > 0 {
+				if retryIn == 0 || delay < retryIn {
+					retryIn = delay
+				}
+				continue
+			}
+			if !p.acquire(idx) {
+				saturated = true
+				continue
+			}
+			if delay, ok := p.takeToken(idx, now); !ok {
+				atomic.AddInt64(&p.inflight[idx], -1)
+				if retryIn == 0 || delay < retryIn {
+					retryIn = delay
+				}
+				continue
+			}
+			tried[idx] = true
+			start := time.Now()
+			err = fn(client)
+			latency := time.Since(start)
+			p.release(idx)
+			if err == nil {
+				// failures return early or hang until timeout, they would skew the average
+				p.recordLatency(idx, latency)
+				return nil
+			}
+			if err == errNullResult {
+				log.Debug("RPC request returned null", "url", client.url, "method", method)
+				continue
+			}
+			class := classifyError(err)
+			if class == ErrRateLimited {
+				// the client is tried again once its backoff has passed
+				backoff := client.rateLimitBackoff(err)
+				p.throttle(idx, client, backoff)
+				if rateLimited++; rateLimited <= maxRateLimitRetries {
+					tried[idx] = false
+					if retryIn == 0 || backoff < retryIn {
+						retryIn = backoff
+					}
+				}
+				continue
+			}
+			// the deadline of the caller is not a failure of the endpoint
+			if ctx.Err() != nil {
+				return classify(err)
+			}
+			// the other clients would reject the request the same way, including the
+			// domain errors of the server like nonce too low and log ranges to be split,
+			// other server errors like gateway failures are retried on the next client
+			if isCallerError(class) || class == nil && isDomainError(err) || isLogRangeError(err) {
+				return classify(err)
+			}
 			log.Warn("RPC request failed", "url", client.url, "method", method, "error", err)
-			if err != ethereum.NotFound && err != rpc.ErrNoResult {
+			if isOutage(class) {
 				go p.cooldown(idx, client)
 			}
-		} else {
-			atomic.StoreInt64(&p.status[idx], 0)
-			return nil
+		}
+		if !saturated && retryIn == 0 {
+			break
+		}
+		var (
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+		if retryIn > 0 {
+			timer = time.NewTimer(retryIn)
+			timeout = timer.C
+		}
+		select {
+		case <-released:
+		case <-timeout:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 	}
-	return err
+	if !capable {
+		return fmt.Errorf("no client can serve %s", method)
+	}
+	if err == nil {
+		return fmt.Errorf("no healthy client available for %s", method)
+	}
+	return classify(err)
 }
 
-func (p *RpcConnectionPool) BatchCall(ctx context.Context, batch []rpc.BatchElem) error {
-	var err error
-	allBusy := true
-	for idx, client := range p.clients {
-		if !atomic.CompareAndSwapInt64(&p.status[idx], 0, 1) {
-			continue
-		}
-		allBusy = false
-		err = client.BatchCall(ctx, batch)
-		if err == nil {
-			err = getBatchErr(batch)
+func (p *RpcConnectionPool) Call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	r := routeRequest(method, args)
+	if !nullRetryMethods[method] {
+		return p.execute(ctx, method, r, func(client *ETHClient) error {
+			return client.Call(ctx, result, method, args...)
+		})
+	}
+	err := p.execute(ctx, method, r, func(client *ETHClient) error {
+		var raw json.RawMessage
+		if err := client.Call(ctx, &raw, method, args...); err != nil {
+			return err
 		}
-		if err != nil {
-			log.Warn("RPC batch request failed", "url", client.url, "count", len(batch), "error", err)
-			go p.cooldown(idx, client)
-		} else {
-			atomic.StoreInt64(&p.status[idx], 0)
-			return nil
+		if isNullResult(raw) {
+			// blocks above the best head are expected to not exist yet
+			if r.number > p.bestHead() {
+				return nil
+			}
+			return errNullResult
 		}
-	}
-	if allBusy {
-		return fmt.Errorf("all clients are busy")
+		return json.Unmarshal(raw, result)
+	})
+	if err == errNullResult {
+		return nil
 	}
 	return err
 }
 
+func (p *RpcConnectionPool) BatchCall(ctx context.Context, batch []rpc.BatchElem) error {
+	var r route
+	for _, elem := range batch {
+		r = r.merge(routeRequest(elem.Method, elem.Args))
+	}
+	method := fmt.Sprintf("batch of %d requests", len(batch))
+	return p.execute(ctx, method, r, func(client *ETHClient) error {
+		if err := client.BatchCall(ctx, batch); err != nil {
+			return err
+		}
+		return getBatchErr(batch)
+	})
+}
+
 func NewRpcConnectionPool(clients []*ETHClient) *RpcConnectionPool {
-	return &RpcConnectionPool{
-		clients: clients,
-		status:  make([]int64, len(clients)),
-		quitCh:  make(chan struct{}),
+	return newRpcConnectionPool(clients, nil)
+}
+
+func newRpcConnectionPool(clients []*ETHClient, config *PoolConfig) *RpcConnectionPool {
+	p := &RpcConnectionPool{
+		clients:     clients,
+		status:      make([]int64, len(clients)),
+		heads:       make([]uint64, len(clients)),
+		maxHeadLag:  defaultMaxHeadLag,
+		inflight:    make([]int64, len(clients)),
+		maxInFlight: defaultMaxInFlight,
+		latency:     make([]int64, len(clients)),
+		limiters:    make([]*tokenBucket, len(clients)),
+		throttled:   make([]int64, len(clients)),
+		slotCh:      make(chan struct{}),
+		quitCh:      make(chan struct{}),
+	}
+	for idx, client := range clients {
+		p.latency[idx] = int64(client.Latency())
+	}
+	if config != nil {
+		if config.MaxHeadLag > 0 {
+			p.maxHeadLag = config.MaxHeadLag
+		}
+		if config.MaxInFlight > 0 {
+			p.maxInFlight = int64(config.MaxInFlight)
+		}
+		p.strategy = config.Strategy
+		for idx, client := range clients {
+			limit, ok := config.EndpointRateLimits[client.url]
+			if !ok {
+				limit = config.RateLimit
+			}
+			p.limiters[idx] = newTokenBucket(limit)
+		}
+	}
+	go p.headLoop()
+	if config != nil && config.ChainID != nil {
+		p.chainID, p.networkID = config.ChainID, config.NetworkID
+		go p.healthCheckLoop()
 	}
+	return p
 }
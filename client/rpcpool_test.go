@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowGasService answers eth_gasPrice after a delay and records the peak concurrency.
+type slowGasService struct {
+	mu             sync.Mutex
+	inflight, peak int
+}
+
+func (s *slowGasService) GasPrice() *hexutil.Big {
+	s.mu.Lock()
+	if s.inflight++; s.inflight > s.peak {
+		s.peak = s.inflight
+	}
+	s.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	s.mu.Lock()
+	s.inflight--
+	s.mu.Unlock()
+	return (*hexutil.Big)(big.NewInt(1))
+}
+
+func TestPoolWaitsForFreeSlot(t *testing.T) {
+	service := &slowGasService{}
+	pool := newRpcConnectionPool([]*ETHClient{newTestClient(t, service, Capabilities{})}, &PoolConfig{MaxInFlight: 2})
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pool.SuggestGasPrice(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, service.peak)
+
+	// the deadline expires while waiting for a slot
+	assert.True(t, pool.acquire(0))
+	assert.True(t, pool.acquire(0))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := pool.SuggestGasPrice(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// knownTxErrors contains the error messages returned by well-known clients when the
+// submitted transaction is already in their transaction pool.
+var knownTxErrors = []string{
+	"already known",
+	"known transaction",
+	"already imported",
+	"alreadyknown",
+	"transaction already exists",
+	"tx already in mempool",
+}
+
+func isKnownTxError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range knownTxErrors {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNonceTooLowError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "oldnonce")
+}
+
+// sendRawTransaction submits the signed raw transaction, it succeeds if the remote node
+// accepted the transaction or already knows about it.
+func sendRawTransaction(ctx context.Context, client rpcCaller, rawTx []byte) (common.Hash, error) {
+	txHash := crypto.Keccak256Hash(rawTx)
+	var result common.Hash
+	err := client.Call(ctx, &result, "eth_sendRawTransaction", hexutil.Bytes(rawTx))
+	if err == nil {
+		return result, nil
+	}
+	if isKnownTxError(err) {
+		return txHash, nil
+	}
+	if isNonceTooLowError(err) {
+		// nonce is already used, it is only fine if it was used by this very transaction
+		var tx *types.Transaction
+		if client.Call(ctx, &tx, "eth_getTransactionByHash", txHash) == nil && tx != nil {
+			return txHash, nil
+		}
+	}
+	return common.Hash{}, err
+}
+
+func sendTransaction(ctx context.Context, client RemoteChainReader, tx *gethtypes.Transaction) error {
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = client.SendRawTransaction(ctx, rawTx)
+	return err
+}
+
+// SetBroadcastMode enables or disables broadcasting of sent transactions. When enabled,
+// SendTransaction and SendRawTransaction submit the transaction to every healthy client.
+func (p *RpcConnectionPool) SetBroadcastMode(enabled bool) {
+	p.broadcast.Store(enabled)
+}
+
+// BroadcastRawTransaction sends the signed raw transaction to every healthy client in
+// parallel, it succeeds if at least one client accepted the transaction. Clients that are
+// saturated or rate limited are skipped, if no client is available the transaction is
+// sent through the pool instead, waiting for a client.
+func (p *RpcConnectionPool) BroadcastRawTransaction(ctx context.Context, rawTx []byte) (common.Hash, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		txHash   common.Hash
+		sent     bool
+		accepted bool
+		lastErr  error
+		now      = time.Now()
+	)
+	for idx, client := range p.clients {
+		if !p.isHealthy(idx) || p.throttleDelay(idx, now) > 0 || !p.acquire(idx) {
+			continue
+		}
+		if _, ok := p.takeToken(idx, now); !ok {
+			atomic.AddInt64(&p.inflight[idx], -1)
+			continue
+		}
+		sent = true
+		wg.Add(1)
+		go func(idx int, client *ETHClient) {
+			defer wg.Done()
+			hash, err := sendRawTransaction(ctx, client, rawTx)
+			p.release(idx)
+			if classifyError(err) == ErrRateLimited {
+				p.throttle(idx, client, client.rateLimitBackoff(err))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Debug("Failed to broadcast transaction", "url", client.url, "error", err)
+				lastErr = err
+				return
+			}
+			txHash, accepted = hash, true
+		}(idx, client)
+	}
+	if !sent {
+		return sendRawTransaction(ctx, p, rawTx)
+	}
+	wg.Wait()
+	if accepted {
+		return txHash, nil
+	}
+	return common.Hash{}, lastErr
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// txPoolService answers eth_sendRawTransaction with the configured error, the transaction
+// is known to the node if included is set.
+type txPoolService struct {
+	err      error
+	included bool
+	sent     int
+}
+
+func (s *txPoolService) SendRawTransaction(rawTx hexutil.Bytes) (common.Hash, error) {
+	s.sent++
+	if s.err != nil {
+		return common.Hash{}, s.err
+	}
+	return crypto.Keccak256Hash(rawTx), nil
+}
+
+func (s *txPoolService) GetTransactionByHash(hash common.Hash) map[string]interface{} {
+	if !s.included {
+		return nil
+	}
+	return map[string]interface{}{
+		"hash":             hash,
+		"from":             common.Address{},
+		"gas":              "0x5208",
+		"gasPrice":         "0x1",
+		"input":            "0x",
+		"nonce":            "0x0",
+		"transactionIndex": "0x0",
+		"value":            "0x0",
+		"type":             "0x0",
+		"v":                "0x0",
+		"r":                "0x0",
+		"s":                "0x0",
+	}
+}
+
+func testRawTx(t *testing.T) ([]byte, common.Hash) {
+	to := common.HexToAddress("0x01")
+	tx := gethtypes.NewTx(&gethtypes.LegacyTx{To: &to, Gas: 21000, GasPrice: big.NewInt(1), Value: big.NewInt(1)})
+	rawTx, err := tx.MarshalBinary()
+	assert.NoError(t, err)
+	return rawTx, crypto.Keccak256Hash(rawTx)
+}
+
+func TestSendRawTransaction(t *testing.T) {
+	rawTx, txHash := testRawTx(t)
+	tests := []struct {
+		name    string
+		service *txPoolService
+		fail    bool
+	}{
+		{name: "accepted", service: &txPoolService{}},
+		{name: "already known", service: &txPoolService{err: errors.New("already known")}},
+		{name: "known transaction", service: &txPoolService{err: errors.New("known transaction: 0xabc")}},
+		{name: "nonce too low by this transaction", service: &txPoolService{err: errors.New("nonce too low"), included: true}},
+		{name: "nonce too low by another transaction", service: &txPoolService{err: errors.New("nonce too low")}, fail: true},
+		{name: "insufficient funds", service: &txPoolService{err: errors.New("insufficient funds for gas * price + value")}, fail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := newTestClient(t, tt.service, Capabilities{})
+			defer ec.Close()
+			hash, err := ec.SendRawTransaction(context.Background(), rawTx)
+			if tt.fail {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, txHash, hash)
+			}
+		})
+	}
+}
+
+func TestBroadcastRawTransaction(t *testing.T) {
+	rawTx, txHash := testRawTx(t)
+	rejecting := &txPoolService{err: errors.New("nonce too low")}
+	accepting := &txPoolService{}
+	pool := NewRpcConnectionPool([]*ETHClient{
+		newTestClient(t, rejecting, Capabilities{}),
+		newTestClient(t, accepting, Capabilities{}),
+	})
+	defer pool.Close()
+	pool.SetBroadcastMode(true)
+
+	hash, err := pool.SendRawTransaction(context.Background(), rawTx)
+	assert.NoError(t, err)
+	assert.Equal(t, txHash, hash)
+	assert.Equal(t, 1, rejecting.sent)
+	assert.Equal(t, 1, accepting.sent)
+
+	accepting.err = errors.New("nonce too low")
+	_, err = pool.SendRawTransaction(context.Background(), rawTx)
+	assert.ErrorContains(t, err, "nonce too low")
+}
+
+func TestBroadcastHonorsRateLimits(t *testing.T) {
+	rawTx, txHash := testRawTx(t)
+	limited := &txPoolService{err: &rateLimitError{backoff: 60}}
+	accepting := &txPoolService{}
+	pool := NewRpcConnectionPool([]*ETHClient{
+		newTestClient(t, limited, Capabilities{}),
+		newTestClient(t, accepting, Capabilities{}),
+	})
+	defer pool.Close()
+
+	hash, err := pool.BroadcastRawTransaction(context.Background(), rawTx)
+	assert.NoError(t, err)
+	assert.Equal(t, txHash, hash)
+	assert.Greater(t, pool.throttleDelay(0, time.Now()), time.Duration(0))
+
+	// the throttled client is skipped until its backoff has passed
+	_, err = pool.BroadcastRawTransaction(context.Background(), rawTx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, limited.sent)
+	assert.Equal(t, 2, accepting.sent)
+}
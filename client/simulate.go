@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// SimulateBlock is a block to be simulated by eth_simulateV1. The calls are executed in
+// order on top of the state left by the previous calls and blocks.
+type SimulateBlock struct {
+	BlockOverrides *BlockOverrides
+	StateOverrides StateOverride
+	Calls          []ethereum.CallMsg
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b SimulateBlock) MarshalJSON() ([]byte, error) {
+	type blockStateCall struct {
+		BlockOverrides *BlockOverrides `json:"blockOverrides,omitempty"`
+		StateOverrides StateOverride   `json:"stateOverrides,omitempty"`
+		Calls          []interface{}   `json:"calls"`
+	}
+	calls := make([]interface{}, len(b.Calls))
+	for idx, msg := range b.Calls {
+		calls[idx] = toCallArg(msg)
+	}
+	return json.Marshal(blockStateCall{
+		BlockOverrides: b.BlockOverrides,
+		StateOverrides: b.StateOverrides,
+		Calls:          calls,
+	})
+}
+
+// SimulateOptions holds the flags of eth_simulateV1.
+type SimulateOptions struct {
+	Validation     bool // enforce nonce, balance and base fee checks like a real block
+	TraceTransfers bool // report ether transfers as logs of the 0xeeee...eeee address
+}
+
+// SimulateCallError is the error of a simulated call that failed or reverted.
+type SimulateCallError struct {
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Data    hexutil.Bytes `json:"data,omitempty"`
+}
+
+func (e *SimulateCallError) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns the JSON-RPC error code of the failed call.
+func (e *SimulateCallError) ErrorCode() int {
+	return e.Code
+}
+
+// SimulateCallResult is the result of a simulated call.
+type SimulateCallResult struct {
+	ReturnData []byte
+	Logs       types.Logs
+	GasUsed    uint64
+	Status     uint64
+	Error      *SimulateCallError
+}
+
+// SimulatedBlock is a block produced by eth_simulateV1 and the results of its calls. One
+// is returned per requested block, the empty blocks the node inserts when a number
+// override skips heights are left out.
+type SimulatedBlock struct {
+	Header *types.Header
+	Calls  []*SimulateCallResult
+}
+
+type simulateCallResultJSON struct {
+	ReturnData hexutil.Bytes      `json:"returnData"`
+	Logs       types.Logs         `json:"logs"`
+	GasUsed    hexutil.Uint64     `json:"gasUsed"`
+	Status     hexutil.Uint64     `json:"status"`
+	Error      *SimulateCallError `json:"error,omitempty"`
+}
+
+func simulate(ctx context.Context, client rpcCaller, blocks []SimulateBlock, opts *SimulateOptions, number *big.Int) ([]*SimulatedBlock, error) {
+	if opts == nil {
+		opts = &SimulateOptions{}
+	}
+	arg := map[string]interface{}{
+		"blockStateCalls": blocks,
+		"validation":      opts.Validation,
+		"traceTransfers":  opts.TraceTransfers,
+	}
+	var raws []json.RawMessage
+	if err := client.Call(ctx, &raws, "eth_simulateV1", arg, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	headers := make([]*types.Header, len(raws))
+	for idx, raw := range raws {
+		if err := json.Unmarshal(raw, &headers[idx]); err != nil {
+			return nil, err
+		}
+		if headers[idx] == nil || headers[idx].Number == nil {
+			return nil, fmt.Errorf("got invalid simulated block at position %d", idx)
+		}
+	}
+	// the node inserts empty blocks when a number override skips heights, the requested
+	// blocks are matched to the results by number
+	var (
+		results = make([]*SimulatedBlock, len(blocks))
+		pos     int
+	)
+	for idx, block := range blocks {
+		var expected *big.Int
+		if block.BlockOverrides != nil && block.BlockOverrides.Number != nil {
+			expected = block.BlockOverrides.Number
+			for pos < len(raws) && headers[pos].Number.Cmp(expected) < 0 {
+				pos++
+			}
+		}
+		if pos >= len(raws) {
+			return nil, fmt.Errorf("missing simulated block %d of %d", idx, len(blocks))
+		}
+		if expected != nil && headers[pos].Number.Cmp(expected) != 0 {
+			return nil, fmt.Errorf("got simulated block number %v, expected %v", headers[pos].Number, expected)
+		}
+		var resp struct {
+			Calls []simulateCallResultJSON `json:"calls"`
+		}
+		if err := json.Unmarshal(raws[pos], &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Calls) != len(block.Calls) {
+			return nil, fmt.Errorf("got %d call results in simulated block %d, expected %d", len(resp.Calls), idx, len(block.Calls))
+		}
+		result := &SimulatedBlock{
+			Header: headers[pos],
+			Calls:  make([]*SimulateCallResult, len(resp.Calls)),
+		}
+		for i, call := range resp.Calls {
+			result.Calls[i] = &SimulateCallResult{
+				ReturnData: call.ReturnData,
+				Logs:       call.Logs,
+				GasUsed:    uint64(call.GasUsed),
+				Status:     uint64(call.Status),
+				Error:      call.Error,
+			}
+		}
+		results[idx] = result
+		pos++
+	}
+	if pos != len(raws) {
+		return nil, fmt.Errorf("got %d unexpected simulated blocks", len(raws)-pos)
+	}
+	return results, nil
+}
+
+func (ec *ETHClient) Simulate(ctx context.Context, blocks []SimulateBlock, opts *SimulateOptions, blockNumber *big.Int) ([]*SimulatedBlock, error) {
+	return simulate(ctx, ec, blocks, opts, blockNumber)
+}
+
+func (p *RpcConnectionPool) Simulate(ctx context.Context, blocks []SimulateBlock, opts *SimulateOptions, blockNumber *big.Int) ([]*SimulatedBlock, error) {
+	return simulate(ctx, p, blocks, opts, blockNumber)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// simulateService simulates the requested blocks on top of block 100 like eth_simulateV1,
+// inserting empty blocks when a number override skips heights. Every call returns its
+// calldata.
+type simulateService struct{}
+
+type simulateRequest struct {
+	BlockStateCalls []struct {
+		BlockOverrides *struct {
+			Number *hexutil.Big `json:"number"`
+		} `json:"blockOverrides"`
+		Calls []struct {
+			Data hexutil.Bytes `json:"data"`
+		} `json:"calls"`
+	} `json:"blockStateCalls"`
+}
+
+func (s *simulateService) SimulateV1(req simulateRequest, number string) ([]map[string]interface{}, error) {
+	var blocks []map[string]interface{}
+	addBlock := func(number uint64, calls []map[string]interface{}) error {
+		enc, _ := json.Marshal(&types.Header{Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(0)})
+		var block map[string]interface{}
+		if err := json.Unmarshal(enc, &block); err != nil {
+			return err
+		}
+		block["calls"] = calls
+		blocks = append(blocks, block)
+		return nil
+	}
+	next := uint64(101)
+	for _, blockCall := range req.BlockStateCalls {
+		if blockCall.BlockOverrides != nil && blockCall.BlockOverrides.Number != nil {
+			for ; next < blockCall.BlockOverrides.Number.ToInt().Uint64(); next++ {
+				if err := addBlock(next, []map[string]interface{}{}); err != nil {
+					return nil, err
+				}
+			}
+		}
+		calls := make([]map[string]interface{}, len(blockCall.Calls))
+		for idx, call := range blockCall.Calls {
+			calls[idx] = map[string]interface{}{
+				"returnData": call.Data,
+				"logs":       []interface{}{},
+				"gasUsed":    "0x5208",
+				"status":     "0x1",
+			}
+		}
+		if err := addBlock(next, calls); err != nil {
+			return nil, err
+		}
+		next++
+	}
+	return blocks, nil
+}
+
+func TestSimulateSkipsFillerBlocks(t *testing.T) {
+	ec := newTestClient(t, &simulateService{}, Capabilities{SimulateV1: true})
+	defer ec.Close()
+
+	to := common.HexToAddress("0x01")
+	call := func(data byte) ethereum.CallMsg {
+		return ethereum.CallMsg{To: &to, Data: []byte{data}}
+	}
+	blocks := []SimulateBlock{
+		{Calls: []ethereum.CallMsg{call(1)}},
+		{BlockOverrides: &BlockOverrides{Number: big.NewInt(105)}, Calls: []ethereum.CallMsg{call(2), call(3)}},
+		{Calls: []ethereum.CallMsg{call(4)}},
+	}
+	results, err := ec.Simulate(context.Background(), blocks, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	for idx, expected := range []struct {
+		number uint64
+		data   [][]byte
+	}{
+		{101, [][]byte{{1}}},
+		{105, [][]byte{{2}, {3}}},
+		{106, [][]byte{{4}}},
+	} {
+		assert.Equal(t, expected.number, results[idx].Header.Number.Uint64())
+		assert.Len(t, results[idx].Calls, len(expected.data))
+		for i, data := range expected.data {
+			assert.Equal(t, data, results[idx].Calls[i].ReturnData)
+			assert.Equal(t, uint64(21000), results[idx].Calls[i].GasUsed)
+			assert.Equal(t, uint64(1), results[idx].Calls[i].Status)
+		}
+	}
+}
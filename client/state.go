@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// batchCallChunked sends the batch in chunks of at most rpcRequestBatchSize elements and
+// returns the first error of the batch elements, if any.
+func batchCallChunked(ctx context.Context, client rpcCaller, batch []rpc.BatchElem) error {
+	for start := 0; start < len(batch); start += rpcRequestBatchSize {
+		end := start + rpcRequestBatchSize
+		if end > len(batch) {
+			end = len(batch)
+		}
+		if err := client.BatchCall(ctx, batch[start:end]); err != nil {
+			return err
+		}
+		if err := getBatchErr(batch[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func balanceAt(ctx context.Context, client rpcCaller, account common.Address, number *big.Int) (*big.Int, error) {
+	var result hexutil.Big
+	err := client.Call(ctx, &result, "eth_getBalance", account, toBlockNumArg(number))
+	return (*big.Int)(&result), err
+}
+
+func nonceAt(ctx context.Context, client rpcCaller, account common.Address, number *big.Int) (uint64, error) {
+	var result hexutil.Uint64
+	err := client.Call(ctx, &result, "eth_getTransactionCount", account, toBlockNumArg(number))
+	return uint64(result), err
+}
+
+func pendingNonceAt(ctx context.Context, client rpcCaller, account common.Address) (uint64, error) {
+	var result hexutil.Uint64
+	err := client.Call(ctx, &result, "eth_getTransactionCount", account, "pending")
+	return uint64(result), err
+}
+
+func storageAt(ctx context.Context, client rpcCaller, account common.Address, key common.Hash, number *big.Int) ([]byte, error) {
+	var result hexutil.Bytes
+	err := client.Call(ctx, &result, "eth_getStorageAt", account, key, toBlockNumArg(number))
+	return result, err
+}
+
+func balancesAt(ctx context.Context, client rpcCaller, accounts []common.Address, number *big.Int) ([]*big.Int, error) {
+	results := make([]hexutil.Big, len(accounts))
+	batch := make([]rpc.BatchElem, len(accounts))
+	for idx, account := range accounts {
+		batch[idx] = rpc.BatchElem{
+			Method: "eth_getBalance",
+			Args:   []interface{}{account, toBlockNumArg(number)},
+			Result: &results[idx],
+		}
+	}
+	if err := batchCallChunked(ctx, client, batch); err != nil {
+		return nil, err
+	}
+	balances := make([]*big.Int, len(accounts))
+	for idx := range results {
+		balances[idx] = (*big.Int)(&results[idx])
+	}
+	return balances, nil
+}
+
+func noncesAt(ctx context.Context, client rpcCaller, accounts []common.Address, number *big.Int) ([]uint64, error) {
+	results := make([]hexutil.Uint64, len(accounts))
+	batch := make([]rpc.BatchElem, len(accounts))
+	for idx, account := range accounts {
+		batch[idx] = rpc.BatchElem{
+			Method: "eth_getTransactionCount",
+			Args:   []interface{}{account, toBlockNumArg(number)},
+			Result: &results[idx],
+		}
+	}
+	if err := batchCallChunked(ctx, client, batch); err != nil {
+		return nil, err
+	}
+	nonces := make([]uint64, len(accounts))
+	for idx, nonce := range results {
+		nonces[idx] = uint64(nonce)
+	}
+	return nonces, nil
+}
+
+func storagesAt(ctx context.Context, client rpcCaller, account common.Address, keys []common.Hash, number *big.Int) ([][]byte, error) {
+	results := make([]hexutil.Bytes, len(keys))
+	batch := make([]rpc.BatchElem, len(keys))
+	for idx, key := range keys {
+		batch[idx] = rpc.BatchElem{
+			Method: "eth_getStorageAt",
+			Args:   []interface{}{account, key, toBlockNumArg(number)},
+			Result: &results[idx],
+		}
+	}
+	if err := batchCallChunked(ctx, client, batch); err != nil {
+		return nil, err
+	}
+	values := make([][]byte, len(keys))
+	for idx, value := range results {
+		values[idx] = value
+	}
+	return values, nil
+}
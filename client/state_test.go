@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// stateService derives the state of an account from its address, the account 0xdead does
+// not exist on the node.
+type stateService struct{}
+
+var errUnknownAccount = errors.New("unknown account")
+
+func (s *stateService) GetBalance(account common.Address, number string) (*hexutil.Big, error) {
+	if account == common.HexToAddress("0xdead") {
+		return nil, errUnknownAccount
+	}
+	return (*hexutil.Big)(account.Hash().Big()), nil
+}
+
+func (s *stateService) GetTransactionCount(account common.Address, number string) hexutil.Uint64 {
+	if number == "pending" {
+		return hexutil.Uint64(account.Hash().Big().Uint64() + 1)
+	}
+	return hexutil.Uint64(account.Hash().Big().Uint64())
+}
+
+func (s *stateService) GetStorageAt(account common.Address, key common.Hash, number string) hexutil.Bytes {
+	return key.Bytes()
+}
+
+// batchCounter records the size of the batches sent to the client.
+type batchCounter struct {
+	rpcCaller
+	batches []int
+}
+
+func (c *batchCounter) BatchCall(ctx context.Context, batch []rpc.BatchElem) error {
+	c.batches = append(c.batches, len(batch))
+	return c.rpcCaller.BatchCall(ctx, batch)
+}
+
+func TestBatchedStateReads(t *testing.T) {
+	ec := newTestClient(t, &stateService{}, Capabilities{})
+	defer ec.Close()
+	caller := &batchCounter{rpcCaller: ec}
+
+	accounts := make([]common.Address, 2*rpcRequestBatchSize+3)
+	for idx := range accounts {
+		accounts[idx] = common.BigToAddress(big.NewInt(int64(idx + 1)))
+	}
+	balances, err := balancesAt(context.Background(), caller, accounts, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{rpcRequestBatchSize, rpcRequestBatchSize, 3}, caller.batches)
+	for idx, balance := range balances {
+		assert.Equal(t, big.NewInt(int64(idx+1)), balance)
+	}
+
+	nonces, err := ec.NoncesAt(context.Background(), accounts[:3], big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, nonces)
+	nonce, err := ec.PendingNonceAt(context.Background(), accounts[0])
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), nonce)
+
+	keys := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}
+	values, err := ec.StoragesAt(context.Background(), accounts[0], keys, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{keys[0].Bytes(), keys[1].Bytes()}, values)
+}
+
+func TestBatchedStateReadsElementError(t *testing.T) {
+	ec := newTestClient(t, &stateService{}, Capabilities{})
+	defer ec.Close()
+	caller := &batchCounter{rpcCaller: ec}
+
+	accounts := make([]common.Address, rpcRequestBatchSize+1)
+	accounts[0] = common.HexToAddress("0xdead")
+	_, err := balancesAt(context.Background(), caller, accounts, nil)
+	assert.ErrorContains(t, err, errUnknownAccount.Error())
+	// the remaining chunks are not sent once a chunk failed
+	assert.Equal(t, []int{rpcRequestBatchSize}, caller.batches)
+}
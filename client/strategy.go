@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ewmaLatencyWeight is the weight of the latest sample in the latency moving average.
+const ewmaLatencyWeight = 0.2
+
+// ClientStats is the state of a pool client seen by a Strategy.
+type ClientStats struct {
+	URL      string
+	InFlight int64         // number of requests being served
+	Latency  time.Duration // exponentially weighted moving average of the request latency
+}
+
+// Strategy decides the order in which the pool tries its clients for a request, the
+// clients after the first one are used for failover.
+type Strategy interface {
+	// Order returns the indexes of the clients in the order they should be tried.
+	Order(clients []ClientStats) []int
+}
+
+func sequence(n int) []int {
+	order := make([]int, n)
+	for idx := range order {
+		order[idx] = idx
+	}
+	return order
+}
+
+type roundRobinStrategy struct {
+	next uint64
+}
+
+// NewRoundRobinStrategy returns a strategy starting each request on the next client.
+func NewRoundRobinStrategy() Strategy {
+	return &roundRobinStrategy{}
+}
+
+func (s *roundRobinStrategy) Order(clients []ClientStats) []int {
+	order := make([]int, len(clients))
+	if len(clients) == 0 {
+		return order
+	}
+	start := int((atomic.AddUint64(&s.next, 1) - 1) % uint64(len(clients)))
+	for idx := range order {
+		order[idx] = (start + idx) % len(clients)
+	}
+	return order
+}
+
+type weightedStrategy struct {
+	weights map[string]float64
+}
+
+// NewWeightedStrategy returns a strategy starting each request on a random client with
+// probability proportional to the weight of its url. Clients without weight have weight 1.
+func NewWeightedStrategy(weights map[string]float64) Strategy {
+	return &weightedStrategy{weights: weights}
+}
+
+func (s *weightedStrategy) Order(clients []ClientStats) []int {
+	// weighted random permutation, sorting by u^(1/w) descending
+	keys := make([]float64, len(clients))
+	for idx, client := range clients {
+		weight, ok := s.weights[client.URL]
+		if !ok {
+			weight = 1
+		}
+		if weight > 0 {
+			keys[idx] = math.Pow(rand.Float64(), 1/weight)
+		}
+	}
+	order := sequence(len(clients))
+	sort.SliceStable(order, func(i, j int) bool {
+		return keys[order[i]] > keys[order[j]]
+	})
+	return order
+}
+
+type leastInFlightStrategy struct{}
+
+// NewLeastInFlightStrategy returns a strategy preferring the clients serving the fewest
+// requests.
+func NewLeastInFlightStrategy() Strategy {
+	return leastInFlightStrategy{}
+}
+
+func (leastInFlightStrategy) Order(clients []ClientStats) []int {
+	order := sequence(len(clients))
+	sort.SliceStable(order, func(i, j int) bool {
+		return clients[order[i]].InFlight < clients[order[j]].InFlight
+	})
+	return order
+}
+
+type ewmaLatencyStrategy struct{}
+
+// NewEWMALatencyStrategy returns a strategy preferring the clients with the lowest
+// moving average of the request latency.
+func NewEWMALatencyStrategy() Strategy {
+	return ewmaLatencyStrategy{}
+}
+
+func (ewmaLatencyStrategy) Order(clients []ClientStats) []int {
+	order := sequence(len(clients))
+	sort.SliceStable(order, func(i, j int) bool {
+		return clients[order[i]].Latency < clients[order[j]].Latency
+	})
+	return order
+}
+
+type strategyKey struct{}
+
+// withStrategy returns a context making the pool order its clients by the given strategy
+// instead of the strategy of the pool for the requests made with it.
+func withStrategy(ctx context.Context, strategy Strategy) context.Context {
+	return context.WithValue(ctx, strategyKey{}, strategy)
+}
+
+// order returns the order in which the clients are tried for a request.
+func (p *RpcConnectionPool) order(ctx context.Context) []int {
+	strategy := p.strategy
+	if override, ok := ctx.Value(strategyKey{}).(Strategy); ok {
+		strategy = override
+	}
+	if strategy == nil {
+		return sequence(len(p.clients))
+	}
+	stats := make([]ClientStats, len(p.clients))
+	for idx, client := range p.clients {
+		stats[idx] = ClientStats{
+			URL:      client.url,
+			InFlight: atomic.LoadInt64(&p.inflight[idx]),
+			Latency:  time.Duration(atomic.LoadInt64(&p.latency[idx])),
+		}
+	}
+	return strategy.Order(stats)
+}
+
+// recordLatency adds a latency sample of the client to its moving average.
+func (p *RpcConnectionPool) recordLatency(idx int, latency time.Duration) {
+	sample := float64(latency)
+	for {
+		old := atomic.LoadInt64(&p.latency[idx])
+		avg := int64(sample)
+		if old > 0 {
+			avg = int64(ewmaLatencyWeight*sample + (1-ewmaLatencyWeight)*float64(old))
+		}
+		if atomic.CompareAndSwapInt64(&p.latency[idx], old, avg) {
+			return
+		}
+	}
+}
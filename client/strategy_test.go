@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundRobinStrategy(t *testing.T) {
+	strategy := NewRoundRobinStrategy()
+	clients := make([]ClientStats, 3)
+	assert.Equal(t, []int{0, 1, 2}, strategy.Order(clients))
+	assert.Equal(t, []int{1, 2, 0}, strategy.Order(clients))
+	assert.Equal(t, []int{2, 0, 1}, strategy.Order(clients))
+	assert.Equal(t, []int{0, 1, 2}, strategy.Order(clients))
+}
+
+func TestWeightedStrategy(t *testing.T) {
+	strategy := NewWeightedStrategy(map[string]float64{"a": 3, "c": 0})
+	clients := []ClientStats{{URL: "a"}, {URL: "b"}, {URL: "c"}}
+	first := make(map[int]int)
+	for i := 0; i < 10000; i++ {
+		order := strategy.Order(clients)
+		assert.Equal(t, 2, order[2])
+		first[order[0]]++
+	}
+	assert.InDelta(t, 7500, first[0], 300)
+}
+
+func TestLeastInFlightStrategy(t *testing.T) {
+	clients := []ClientStats{{InFlight: 4}, {InFlight: 1}, {InFlight: 2}, {InFlight: 1}}
+	assert.Equal(t, []int{1, 3, 2, 0}, NewLeastInFlightStrategy().Order(clients))
+}
+
+func TestEWMALatencyStrategy(t *testing.T) {
+	clients := []ClientStats{{Latency: 90 * time.Millisecond}, {Latency: 20 * time.Millisecond}, {Latency: 50 * time.Millisecond}}
+	assert.Equal(t, []int{1, 2, 0}, NewEWMALatencyStrategy().Order(clients))
+}
+
+// countingStrategy keeps the client order and counts how often it is asked for it.
+type countingStrategy struct {
+	calls int
+}
+
+func (s *countingStrategy) Order(clients []ClientStats) []int {
+	s.calls++
+	return sequence(len(clients))
+}
+
+func TestPoolOrdersOncePerRequest(t *testing.T) {
+	strategy := &countingStrategy{}
+	pool := newRpcConnectionPool([]*ETHClient{newTestClient(t, &limitedGasService{}, Capabilities{})}, &PoolConfig{Strategy: strategy})
+	defer pool.Close()
+
+	// the rate limited client is retried without asking the strategy again
+	_, err := pool.SuggestGasPrice(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strategy.calls)
+}
+
+func TestPoolRecordsLatencyOfSuccessfulRequests(t *testing.T) {
+	pool := NewRpcConnectionPool([]*ETHClient{
+		newTestClient(t, &revertingService{}, Capabilities{}),
+		newTestClient(t, &limitedGasService{calls: 1}, Capabilities{}),
+	})
+	defer pool.Close()
+
+	_, err := pool.CallContract(context.Background(), ethereum.CallMsg{}, big.NewInt(1))
+	assert.Error(t, err)
+	assert.Zero(t, pool.latency[0])
+
+	_, err = pool.SuggestGasPrice(context.Background())
+	assert.NoError(t, err)
+	assert.NotZero(t, pool.latency[1])
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	rpcResubscribeInterval = 3 * time.Second
+	rpcRequestTimeout      = 30 * time.Second
+	headerGapRetries       = 3
+	headerGapRetryInterval = 1 * time.Second
+)
+
+var big1 = big.NewInt(1)
+
+// supportsSubscriptions reports whether the endpoint url uses a transport that supports
+// notifications, which are websocket and IPC.
+func supportsSubscriptions(url string) bool {
+	return !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://")
+}
+
+// resubscribe retries the subscription until it succeeds or quit is closed. The
+// underlying rpc.Client redials the lost websocket connection on the next request.
+func (ec *ETHClient) resubscribe(quit <-chan struct{}, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, bool) {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), rpcDialTimeout)
+		sub, err := ec.client.EthSubscribe(ctx, channel, args...)
+		cancel()
+		if err == nil {
+			log.Info("Resubscribed to RPC endpoint", "url", ec.url, "subscription", args[0])
+			return sub, true
+		}
+		log.Debug("Failed to resubscribe to RPC endpoint", "url", ec.url, "subscription", args[0], "error", err)
+		select {
+		case <-quit:
+			return nil, false
+		case <-time.After(rpcResubscribeInterval):
+		}
+	}
+}
+
+// fillHeaderGap delivers the headers between from and to (both exclusive), which were
+// missed while the subscription was down. Failed requests are retried, the error is
+// returned if a header still cannot be fetched so that no header is silently skipped.
+func fillHeaderGap(quit <-chan struct{}, reader RemoteChainReader, ch chan<- *types.Header, from, to *big.Int) error {
+	for num := new(big.Int).Add(from, big1); num.Cmp(to) < 0; num.Add(num, big1) {
+		var (
+			header *types.Header
+			err    error
+		)
+		for attempt := 0; attempt < headerGapRetries; attempt++ {
+			if attempt > 0 {
+				select {
+				case <-quit:
+					return errSubscriptionClosed
+				case <-time.After(time.Duration(attempt) * headerGapRetryInterval):
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), rpcRequestTimeout)
+			header, err = reader.HeaderByNumber(ctx, num)
+			cancel()
+			if err == nil {
+				break
+			}
+			log.Debug("Failed to fill missed header", "number", num, "attempt", attempt+1, "error", err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fill missed header %v: %w", num, err)
+		}
+		select {
+		case ch <- header:
+		case <-quit:
+			return errSubscriptionClosed
+		}
+	}
+	return nil
+}
+
+// forwardHeads delivers the headers received on headCh to ch, the headers missed while
+// the subscription was down are fetched from reader. resubscribe is called to replace the
+// subscription when it drops, it returns false once quit is closed.
+func forwardHeads(quit <-chan struct{}, reader RemoteChainReader, ch chan<- *types.Header, headCh <-chan *types.Header, sub *rpc.ClientSubscription, resubscribe func() (*rpc.ClientSubscription, bool)) error {
+	var lastNumber *big.Int
+	for {
+		select {
+		case header := <-headCh:
+			if lastNumber != nil && header.Number.Cmp(lastNumber) > 0 {
+				if err := fillHeaderGap(quit, reader, ch, lastNumber, header.Number); err != nil {
+					sub.Unsubscribe()
+					if err == errSubscriptionClosed {
+						return nil
+					}
+					return err
+				}
+			}
+			select {
+			case ch <- header:
+			case <-quit:
+				sub.Unsubscribe()
+				return nil
+			}
+			lastNumber = header.Number
+		case err := <-sub.Err():
+			log.Warn("Head subscription dropped", "error", err)
+			var ok bool
+			if sub, ok = resubscribe(); !ok {
+				return nil
+			}
+		case <-quit:
+			sub.Unsubscribe()
+			return nil
+		}
+	}
+}
+
+// SubscribeNewHead subscribes to notifications about new chain heads on websocket and IPC
+// endpoints. The subscription survives connection drops: the client redials, resubscribes
+// and delivers the headers that were missed during the outage. The subscription fails
+// with an error if the missed headers cannot be fetched.
+func (ec *ETHClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	if !supportsSubscriptions(ec.url) {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	headCh := make(chan *types.Header)
+	sub, err := ec.client.EthSubscribe(ctx, headCh, "newHeads")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		return forwardHeads(quit, ec, ch, headCh, sub, func() (*rpc.ClientSubscription, bool) {
+			return ec.resubscribe(quit, headCh, "newHeads")
+		})
+	}), nil
+}
+
+// subscribeNewHead subscribes to new heads with the first healthy client supporting
+// subscriptions, starting from the client at index start.
+func (p *RpcConnectionPool) subscribeNewHead(ctx context.Context, headCh chan *types.Header, start int) (int, *rpc.ClientSubscription, error) {
+	err := rpc.ErrNotificationsUnsupported
+	for i := range p.clients {
+		idx := (start + i) % len(p.clients)
+		client := p.clients[idx]
+		if !supportsSubscriptions(client.url) || !p.isHealthy(idx) {
+			continue
+		}
+		var sub *rpc.ClientSubscription
+		if sub, err = client.client.EthSubscribe(ctx, headCh, "newHeads"); err == nil {
+			return idx, sub, nil
+		}
+		log.Warn("Failed to subscribe to new heads", "url", client.url, "error", err)
+	}
+	return 0, nil, err
+}
+
+// SubscribeNewHead subscribes to new chain heads using the first client that supports
+// subscriptions. When the subscription drops it fails over to the next client, and the
+// headers missed in between are fetched from the pool.
+func (p *RpcConnectionPool) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	headCh := make(chan *types.Header)
+	idx, sub, err := p.subscribeNewHead(ctx, headCh, 0)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		return forwardHeads(quit, p, ch, headCh, sub, func() (*rpc.ClientSubscription, bool) {
+			for {
+				ctx, cancel := context.WithTimeout(context.Background(), rpcDialTimeout)
+				next, sub, err := p.subscribeNewHead(ctx, headCh, idx+1)
+				cancel()
+				if err == nil {
+					log.Info("Resubscribed to new heads", "url", p.clients[next].url)
+					idx = next
+					return sub, true
+				}
+				select {
+				case <-quit:
+					return nil, false
+				case <-time.After(rpcResubscribeInterval):
+				}
+			}
+		})
+	}), nil
+}
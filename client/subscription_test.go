@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/khanghh/ethcore/types"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// headFeedService notifies the headers sent to its feed and serves every header by number,
+// the first failures header requests fail.
+type headFeedService struct {
+	feed event.Feed
+
+	mu         sync.Mutex
+	subscribed int
+	failures   int
+}
+
+func (s *headFeedService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+	headCh := make(chan *types.Header, 16)
+	sub := s.feed.Subscribe(headCh)
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case header := <-headCh:
+				notifier.Notify(rpcSub.ID, header)
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	s.mu.Lock()
+	s.subscribed++
+	s.mu.Unlock()
+	return rpcSub, nil
+}
+
+func (s *headFeedService) GetBlockByNumber(number hexutil.Uint64, fullBlock bool) (*types.Header, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return nil, errors.New("backend unavailable")
+	}
+	return testHeader(uint64(number)), nil
+}
+
+func (s *headFeedService) subscriptions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscribed
+}
+
+func testHeader(number uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(0)}
+}
+
+// wsTestServer serves RPC over websocket and can drop the established connections.
+type wsTestServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (s *wsTestServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func newWebsocketTestClient(t *testing.T, service interface{}) (*ETHClient, *wsTestServer) {
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	assert.NoError(t, server.RegisterName("eth", service))
+	wsServer := &wsTestServer{Server: httptest.NewUnstartedServer(server.WebsocketHandler([]string{"*"}))}
+	wsServer.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateHijacked {
+			wsServer.mu.Lock()
+			wsServer.conns = append(wsServer.conns, conn)
+			wsServer.mu.Unlock()
+		}
+	}
+	wsServer.Start()
+	t.Cleanup(wsServer.Close)
+	url := "ws" + strings.TrimPrefix(wsServer.URL, "http")
+	client, err := rpc.DialWebsocket(context.Background(), url, "")
+	assert.NoError(t, err)
+	ec := &ETHClient{url: url, client: client}
+	ec.caps.Store(&Capabilities{WebSocket: true})
+	return ec, wsServer
+}
+
+func waitSubscriptions(t *testing.T, service *headFeedService, count int) {
+	deadline := time.Now().Add(10 * time.Second)
+	for service.subscriptions() < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d subscriptions", count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func receiveHeaders(t *testing.T, ch <-chan *types.Header, sub interface{ Err() <-chan error }, count int) []uint64 {
+	var numbers []uint64
+	for len(numbers) < count {
+		select {
+		case header := <-ch:
+			numbers = append(numbers, header.Number.Uint64())
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for headers, got %v", numbers)
+		}
+	}
+	return numbers
+}
+
+func TestSubscribeNewHeadResubscribeFillsGap(t *testing.T) {
+	service := &headFeedService{}
+	ec, wsServer := newWebsocketTestClient(t, service)
+	defer ec.Close()
+
+	ch := make(chan *types.Header)
+	sub, err := ec.SubscribeNewHead(context.Background(), ch)
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+	waitSubscriptions(t, service, 1)
+	service.feed.Send(testHeader(1))
+	assert.Equal(t, []uint64{1}, receiveHeaders(t, ch, sub, 1))
+
+	// headers 2 to 4 are produced while the connection is down, the first request filling
+	// the gap fails and is retried
+	wsServer.dropConnections()
+	waitSubscriptions(t, service, 2)
+	service.mu.Lock()
+	service.failures = 1
+	service.mu.Unlock()
+	service.feed.Send(testHeader(5))
+	assert.Equal(t, []uint64{2, 3, 4, 5}, receiveHeaders(t, ch, sub, 4))
+}
+
+func TestSubscribeNewHeadGapFailure(t *testing.T) {
+	service := &headFeedService{}
+	ec, _ := newWebsocketTestClient(t, service)
+	defer ec.Close()
+
+	ch := make(chan *types.Header)
+	sub, err := ec.SubscribeNewHead(context.Background(), ch)
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+	waitSubscriptions(t, service, 1)
+	service.feed.Send(testHeader(1))
+	assert.Equal(t, []uint64{1}, receiveHeaders(t, ch, sub, 1))
+
+	service.mu.Lock()
+	service.failures = headerGapRetries
+	service.mu.Unlock()
+	service.feed.Send(testHeader(3))
+	select {
+	case header := <-ch:
+		t.Fatalf("unexpected header %v delivered before the missed header", header.Number)
+	case err := <-sub.Err():
+		assert.ErrorContains(t, err, "failed to fill missed header 2")
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the subscription error")
+	}
+}
+
+func TestPoolSubscribeNewHeadFailover(t *testing.T) {
+	first, second := &headFeedService{}, &headFeedService{}
+	firstClient, firstServer := newWebsocketTestClient(t, first)
+	secondClient, _ := newWebsocketTestClient(t, second)
+	pool := NewRpcConnectionPool([]*ETHClient{firstClient, secondClient})
+	defer pool.Close()
+
+	ch := make(chan *types.Header)
+	sub, err := pool.SubscribeNewHead(context.Background(), ch)
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+	waitSubscriptions(t, first, 1)
+	first.feed.Send(testHeader(1))
+	assert.Equal(t, []uint64{1}, receiveHeaders(t, ch, sub, 1))
+
+	firstServer.dropConnections()
+	waitSubscriptions(t, second, 1)
+	second.feed.Send(testHeader(4))
+	assert.Equal(t, []uint64{2, 3, 4}, receiveHeaders(t, ch, sub, 3))
+}
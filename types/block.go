@@ -171,7 +171,7 @@ func (b *Block) WithBody(transactions Transactions, uncles []*Header) *Block {
 		block.body.Uncles[i] = CopyHeader(uncle)
 	}
 
-	return b
+	return block
 }
 
 func (b *Block) WithWithdrawals(withdrawals Withdrawals) *Block {
@@ -0,0 +1,25 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockWithBody(t *testing.T) {
+	header := &Header{Hash: common.HexToHash("0x01")}
+	tx := &Transaction{data: txData{Hash: common.HexToHash("0x02")}}
+	uncle := &Header{Hash: common.HexToHash("0x03")}
+
+	block := NewBlockWithHeader(header)
+	withBody := block.WithBody(Transactions{tx}, []*Header{uncle})
+
+	assert.NotSame(t, block, withBody)
+	assert.Nil(t, block.Body())
+	assert.Equal(t, header.Hash, withBody.Hash())
+	assert.Equal(t, Transactions{tx}, withBody.Body().Transactions)
+	assert.Equal(t, []common.Hash{tx.Hash()}, withBody.Transactions())
+	assert.Equal(t, []common.Hash{uncle.Hash}, withBody.Uncles())
+	assert.Equal(t, uncle.Hash, withBody.Body().Uncles[0].Hash)
+}
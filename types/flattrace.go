@@ -0,0 +1,229 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+//go:generate go run github.com/fjl/gencodec -type FlatTrace -out gen_flattrace_json.go
+//go:generate go run github.com/fjl/gencodec -type TraceAction -field-override traceActionMarshaling -out gen_traceaction_json.go
+//go:generate go run github.com/fjl/gencodec -type TraceResult -field-override traceResultMarshaling -out gen_traceresult_json.go
+//go:generate go run github.com/fjl/gencodec -type TraceReplayResult -field-override traceReplayResultMarshaling -out gen_tracereplayresult_json.go
+//go:generate go run github.com/fjl/gencodec -type VmTrace -field-override vmTraceMarshaling -out gen_vmtrace_json.go
+//go:generate go run github.com/fjl/gencodec -type VmMemoryDiff -field-override vmMemoryDiffMarshaling -out gen_vmmemorydiff_json.go
+//go:generate go run github.com/fjl/gencodec -type VmStorageDiff -field-override vmStorageDiffMarshaling -out gen_vmstoragediff_json.go
+
+// Flat trace types.
+const (
+	TraceTypeCall    = "call"
+	TraceTypeCreate  = "create"
+	TraceTypeSuicide = "suicide"
+	TraceTypeReward  = "reward"
+)
+
+// FlatTrace is a single trace of the trace_ namespace implemented by OpenEthereum, Erigon
+// and Nethermind. Nested calls are flattened, their position in the call tree is given
+// by TraceAddress.
+type FlatTrace struct {
+	Action              TraceAction  `json:"action"              gencodec:"required"`
+	BlockHash           *common.Hash `json:"blockHash,omitempty"`
+	BlockNumber         *uint64      `json:"blockNumber,omitempty"`
+	Result              *TraceResult `json:"result"`
+	Error               string       `json:"error,omitempty"`
+	Subtraces           int          `json:"subtraces"`
+	TraceAddress        []int        `json:"traceAddress"        gencodec:"required"`
+	TransactionHash     *common.Hash `json:"transactionHash,omitempty"`
+	TransactionPosition *uint64      `json:"transactionPosition,omitempty"`
+	Type                string       `json:"type"                gencodec:"required"`
+}
+
+// TraceAction is the action of a flat trace, the fields set depend on the trace type.
+type TraceAction struct {
+	// call and create fields
+	CallType       string          `json:"callType,omitempty"`
+	CreationMethod string          `json:"creationMethod,omitempty"`
+	From           *common.Address `json:"from,omitempty"`
+	To             *common.Address `json:"to,omitempty"`
+	Gas            uint64          `json:"gas,omitempty"`
+	Input          []byte          `json:"input,omitempty"`
+	Init           []byte          `json:"init,omitempty"`
+	Value          *big.Int        `json:"value,omitempty"`
+
+	// suicide fields
+	Address       *common.Address `json:"address,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	Balance       *big.Int        `json:"balance,omitempty"`
+
+	// reward fields
+	Author     *common.Address `json:"author,omitempty"`
+	RewardType string          `json:"rewardType,omitempty"`
+}
+
+type traceActionMarshaling struct {
+	Gas     hexutil.Uint64
+	Input   hexutil.Bytes
+	Init    hexutil.Bytes
+	Value   *hexutil.Big
+	Balance *hexutil.Big
+}
+
+// TraceResult is the result of a call or create trace.
+type TraceResult struct {
+	GasUsed uint64          `json:"gasUsed"`
+	Output  []byte          `json:"output,omitempty"`
+	Address *common.Address `json:"address,omitempty"`
+	Code    []byte          `json:"code,omitempty"`
+}
+
+type traceResultMarshaling struct {
+	GasUsed hexutil.Uint64
+	Output  hexutil.Bytes
+	Code    hexutil.Bytes
+}
+
+// TraceReplayResult is the result of replaying a transaction with trace_replayTransaction
+// or trace_replayBlockTransactions. StateDiff and VmTrace are only set when requested.
+type TraceReplayResult struct {
+	Output          []byte                          `json:"output"`
+	StateDiff       map[common.Address]*AccountDiff `json:"stateDiff"`
+	Trace           []*FlatTrace                    `json:"trace"`
+	VmTrace         *VmTrace                        `json:"vmTrace"`
+	TransactionHash *common.Hash                    `json:"transactionHash,omitempty"`
+}
+
+type traceReplayResultMarshaling struct {
+	Output hexutil.Bytes
+}
+
+// State diff kinds.
+const (
+	DiffKindSame    = "="
+	DiffKindBorn    = "+"
+	DiffKindDied    = "-"
+	DiffKindChanged = "*"
+)
+
+// StateDiff is the change of a single account field caused by a transaction.
+// From is set for died and changed values, To is set for born and changed values.
+type StateDiff[T any] struct {
+	Kind string
+	From T
+	To   T
+}
+
+type stateDiffChange[T any] struct {
+	From T `json:"from"`
+	To   T `json:"to"`
+}
+
+// MarshalJSON marshals as JSON.
+func (d StateDiff[T]) MarshalJSON() ([]byte, error) {
+	switch d.Kind {
+	case DiffKindSame, "":
+		return json.Marshal(DiffKindSame)
+	case DiffKindBorn:
+		return json.Marshal(map[string]T{DiffKindBorn: d.To})
+	case DiffKindDied:
+		return json.Marshal(map[string]T{DiffKindDied: d.From})
+	case DiffKindChanged:
+		return json.Marshal(map[string]stateDiffChange[T]{DiffKindChanged: {From: d.From, To: d.To}})
+	}
+	return nil, fmt.Errorf("invalid state diff kind %q", d.Kind)
+}
+
+// UnmarshalJSON unmarshals from JSON.
+func (d *StateDiff[T]) UnmarshalJSON(input []byte) error {
+	var kind string
+	if err := json.Unmarshal(input, &kind); err == nil {
+		if kind != DiffKindSame {
+			return fmt.Errorf("invalid state diff kind %q", kind)
+		}
+		d.Kind = kind
+		return nil
+	}
+	var dec map[string]json.RawMessage
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if len(dec) != 1 {
+		return fmt.Errorf("invalid state diff %s", input)
+	}
+	for kind, raw := range dec {
+		d.Kind = kind
+		switch kind {
+		case DiffKindBorn:
+			return json.Unmarshal(raw, &d.To)
+		case DiffKindDied:
+			return json.Unmarshal(raw, &d.From)
+		case DiffKindChanged:
+			var change stateDiffChange[T]
+			if err := json.Unmarshal(raw, &change); err != nil {
+				return err
+			}
+			d.From, d.To = change.From, change.To
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid state diff kind %q", d.Kind)
+}
+
+// AccountDiff is the state diff of an account.
+type AccountDiff struct {
+	Balance StateDiff[*hexutil.Big]                `json:"balance"`
+	Nonce   StateDiff[hexutil.Uint64]              `json:"nonce"`
+	Code    StateDiff[hexutil.Bytes]               `json:"code"`
+	Storage map[common.Hash]StateDiff[common.Hash] `json:"storage"`
+}
+
+// VmTrace is the virtual machine execution trace of a transaction or a sub call.
+type VmTrace struct {
+	Code []byte        `json:"code"`
+	Ops  []VmOperation `json:"ops"`
+}
+
+type vmTraceMarshaling struct {
+	Code hexutil.Bytes
+}
+
+// VmOperation is a single executed instruction of a vm trace.
+type VmOperation struct {
+	Cost uint64               `json:"cost"`
+	Ex   *VmExecutedOperation `json:"ex"`
+	Pc   uint64               `json:"pc"`
+	Sub  *VmTrace             `json:"sub"`
+	Op   string               `json:"op,omitempty"`
+	Idx  string               `json:"idx,omitempty"`
+}
+
+// VmExecutedOperation describes the effects of an executed instruction.
+type VmExecutedOperation struct {
+	Used  uint64         `json:"used"`
+	Push  []*hexutil.Big `json:"push"`
+	Mem   *VmMemoryDiff  `json:"mem"`
+	Store *VmStorageDiff `json:"store"`
+}
+
+// VmMemoryDiff is a memory write of an executed instruction.
+type VmMemoryDiff struct {
+	Off  uint64 `json:"off"`
+	Data []byte `json:"data"`
+}
+
+type vmMemoryDiffMarshaling struct {
+	Data hexutil.Bytes
+}
+
+// VmStorageDiff is a storage write of an executed instruction.
+type VmStorageDiff struct {
+	Key *big.Int `json:"key"`
+	Val *big.Int `json:"val"`
+}
+
+type vmStorageDiffMarshaling struct {
+	Key *hexutil.Big
+	Val *hexutil.Big
+}
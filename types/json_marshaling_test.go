@@ -589,3 +589,92 @@ func TestUnmarshalingReceipt(t *testing.T) {
 	assert.Equal(t, len(receipt.Logs), 2)
 	assert.Equal(t, receipt.Logs[0].Address, common.HexToAddress("0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d"))
 }
+
+func TestUnmarshalingCallFrame(t *testing.T) {
+	const callFrameJson = `{
+		"from": "0x0f102a3ee067b8d027dffa233fdfed1f2e8a945d",
+		"gas": "0x2dc6c0",
+		"gasUsed": "0x1b9a3",
+		"to": "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d",
+		"input": "0xa22cb465",
+		"output": "0x",
+		"calls": [
+			{
+				"from": "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d",
+				"gas": "0x8fc",
+				"gasUsed": "0x0",
+				"to": "0x098c063d6308cd47d2d67ca17733c34f91dec0af",
+				"input": "0x",
+				"value": "0xde0b6b3a7640000",
+				"type": "CALL"
+			}
+		],
+		"value": "0x0",
+		"type": "CALL"
+	}`
+	var frame *CallFrame
+	if err := json.Unmarshal([]byte(callFrameJson), &frame); err != nil {
+		panic(err)
+	}
+	assert.Equal(t, frame.Type, "CALL")
+	assert.Equal(t, frame.GasUsed, uint64(0x1b9a3))
+	assert.Equal(t, frame.Input, hexutil.MustDecode("0xa22cb465"))
+	assert.Equal(t, len(frame.Calls), 1)
+	assert.Equal(t, *frame.Calls[0].To, common.HexToAddress("0x098c063d6308cd47d2d67ca17733c34f91dec0af"))
+	assert.Equal(t, hexutil.EncodeBig(frame.Calls[0].Value), "0xde0b6b3a7640000")
+}
+
+func TestUnmarshalingTraceReplayResult(t *testing.T) {
+	const replayJson = `{
+		"output": "0x",
+		"stateDiff": {
+			"0x0f102a3ee067b8d027dffa233fdfed1f2e8a945d": {
+				"balance": {"*": {"from": "0x1bc16d674ec80000", "to": "0x1bc16d674ec7ff00"}},
+				"code": "=",
+				"nonce": {"*": {"from": "0x1", "to": "0x2"}},
+				"storage": {}
+			},
+			"0x098c063d6308cd47d2d67ca17733c34f91dec0af": {
+				"balance": {"+": "0x100"},
+				"code": {"+": "0x"},
+				"nonce": {"+": "0x0"},
+				"storage": {
+					"0x0000000000000000000000000000000000000000000000000000000000000001": {"+": "0x00000000000000000000000000000000000000000000000000000000000000af"}
+				}
+			}
+		},
+		"trace": [
+			{
+				"action": {
+					"callType": "call",
+					"from": "0x0f102a3ee067b8d027dffa233fdfed1f2e8a945d",
+					"gas": "0x2dc6c0",
+					"input": "0x",
+					"to": "0x098c063d6308cd47d2d67ca17733c34f91dec0af",
+					"value": "0x100"
+				},
+				"result": {"gasUsed": "0x0", "output": "0x"},
+				"subtraces": 0,
+				"traceAddress": [],
+				"type": "call"
+			}
+		],
+		"vmTrace": null
+	}`
+	var result *TraceReplayResult
+	if err := json.Unmarshal([]byte(replayJson), &result); err != nil {
+		panic(err)
+	}
+	sender := result.StateDiff[common.HexToAddress("0x0f102a3ee067b8d027dffa233fdfed1f2e8a945d")]
+	assert.Equal(t, sender.Balance.Kind, DiffKindChanged)
+	assert.Equal(t, sender.Balance.To.String(), "0x1bc16d674ec7ff00")
+	assert.Equal(t, sender.Code.Kind, DiffKindSame)
+	assert.Equal(t, uint64(sender.Nonce.To), uint64(2))
+	receiver := result.StateDiff[common.HexToAddress("0x098c063d6308cd47d2d67ca17733c34f91dec0af")]
+	assert.Equal(t, receiver.Balance.Kind, DiffKindBorn)
+	assert.Equal(t, receiver.Storage[common.HexToHash("0x1")].To, common.HexToHash("0xaf"))
+	assert.Equal(t, len(result.Trace), 1)
+	assert.Equal(t, result.Trace[0].Type, TraceTypeCall)
+	assert.Equal(t, hexutil.EncodeBig(result.Trace[0].Action.Value), "0x100")
+	assert.Nil(t, result.VmTrace)
+}
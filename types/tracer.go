@@ -0,0 +1,78 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+//go:generate go run github.com/fjl/gencodec -type CallFrame -field-override callFrameMarshaling -out gen_callframe_json.go
+//go:generate go run github.com/fjl/gencodec -type CallLog -field-override callLogMarshaling -out gen_calllog_json.go
+//go:generate go run github.com/fjl/gencodec -type PrestateAccount -field-override prestateAccountMarshaling -out gen_prestateaccount_json.go
+
+// CallFrame is a call frame produced by the built-in callTracer, nested calls made by
+// the frame are listed in Calls.
+type CallFrame struct {
+	Type         string          `json:"type"          gencodec:"required"`
+	From         common.Address  `json:"from"          gencodec:"required"`
+	Gas          uint64          `json:"gas"`
+	GasUsed      uint64          `json:"gasUsed"`
+	To           *common.Address `json:"to,omitempty"`
+	Input        []byte          `json:"input"`
+	Output       []byte          `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []CallFrame     `json:"calls,omitempty"`
+	Logs         []CallLog       `json:"logs,omitempty"`
+	Value        *big.Int        `json:"value,omitempty"`
+}
+
+type callFrameMarshaling struct {
+	Gas     hexutil.Uint64
+	GasUsed hexutil.Uint64
+	Input   hexutil.Bytes
+	Output  hexutil.Bytes
+	Value   *hexutil.Big
+}
+
+// CallLog is a log emitted inside a call frame when callTracer is configured withLog.
+type CallLog struct {
+	Address  common.Address `json:"address"`
+	Topics   []common.Hash  `json:"topics"`
+	Data     []byte         `json:"data"`
+	Position uint           `json:"position"` // index of the log among the sub calls of the frame
+}
+
+type callLogMarshaling struct {
+	Data     hexutil.Bytes
+	Position hexutil.Uint
+}
+
+// PrestateAccount is the state of an account reported by the built-in prestateTracer.
+type PrestateAccount struct {
+	Balance *big.Int                    `json:"balance,omitempty"`
+	Code    []byte                      `json:"code,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+type prestateAccountMarshaling struct {
+	Balance *hexutil.Big
+	Code    hexutil.Bytes
+}
+
+// PrestateResult is the result of prestateTracer, it contains the accounts touched by
+// the transaction and their state before execution.
+type PrestateResult map[common.Address]*PrestateAccount
+
+// PrestateDiff is the result of prestateTracer in diffMode. Post only contains the
+// modified fields of each account, accounts deleted by the transaction are only in Pre.
+type PrestateDiff struct {
+	Pre  PrestateResult `json:"pre"`
+	Post PrestateResult `json:"post"`
+}
+
+// FourByteResult is the result of 4byteTracer, it maps each "selector-calldatasize" pair
+// to the number of times it was called.
+type FourByteResult map[string]int